@@ -0,0 +1,3 @@
+INSERT INTO api_keys (key_name, key_hash, roles, customer_id) VALUES
+($1, $2, $3, $4)
+RETURNING id, key_name, roles, customer_id, created_at, revoked_at;
\ No newline at end of file
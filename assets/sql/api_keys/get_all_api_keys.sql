@@ -0,0 +1,3 @@
+SELECT k.id, k.key_name, k.roles, k.customer_id, k.created_at, k.revoked_at
+FROM api_keys k
+ORDER BY k.id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
\ No newline at end of file
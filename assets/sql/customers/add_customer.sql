@@ -0,0 +1,3 @@
+INSERT INTO customers (company_name, company_address, tax_id, email, phone_number, jurisdiction) VALUES
+($1, $2, $3, $4, $5, $6)
+RETURNING id, company_name, company_address, tax_id, email, phone_number, jurisdiction;
\ No newline at end of file
@@ -0,0 +1,2 @@
+SELECT COUNT(*)
+FROM customers c;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DELETE FROM customers c
+WHERE c.id = $1 AND ($2::INTEGER IS NULL OR c.id = $2);
\ No newline at end of file
@@ -0,0 +1,2 @@
+SELECT c.id, c.company_name, c.company_address, c.tax_id, c.email, c.phone_number, c.jurisdiction
+FROM customers c;
\ No newline at end of file
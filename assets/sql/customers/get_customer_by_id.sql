@@ -0,0 +1,3 @@
+SELECT c.id, c.company_name, c.company_address, c.tax_id, c.email, c.phone_number, c.jurisdiction
+FROM customers c
+WHERE c.id = $1 AND ($2::INTEGER IS NULL OR c.id = $2);
\ No newline at end of file
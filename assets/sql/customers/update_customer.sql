@@ -0,0 +1,3 @@
+UPDATE customers
+SET company_name = $2, company_address = $3, tax_id = $4, email = $5, phone_number = $6, jurisdiction = $7
+WHERE id = $1 AND ($8::INTEGER IS NULL OR id = $8);
\ No newline at end of file
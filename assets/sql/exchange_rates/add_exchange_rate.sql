@@ -0,0 +1,3 @@
+INSERT INTO exchange_rates (base_currency, quote_currency, rate, effective_date) VALUES
+($1, $2, $3, $4)
+RETURNING id, base_currency, quote_currency, rate, effective_date;
\ No newline at end of file
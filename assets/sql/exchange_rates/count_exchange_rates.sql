@@ -0,0 +1,2 @@
+SELECT COUNT(*)
+FROM exchange_rates er;
\ No newline at end of file
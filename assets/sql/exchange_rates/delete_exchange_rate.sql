@@ -0,0 +1,2 @@
+DELETE FROM exchange_rates er
+WHERE er.id = $1;
\ No newline at end of file
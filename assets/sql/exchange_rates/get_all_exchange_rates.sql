@@ -0,0 +1,2 @@
+SELECT er.id, er.base_currency, er.quote_currency, er.rate, er.effective_date
+FROM exchange_rates er;
\ No newline at end of file
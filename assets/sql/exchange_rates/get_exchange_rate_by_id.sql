@@ -0,0 +1,3 @@
+SELECT er.id, er.base_currency, er.quote_currency, er.rate, er.effective_date
+FROM exchange_rates er
+WHERE er.id = $1;
\ No newline at end of file
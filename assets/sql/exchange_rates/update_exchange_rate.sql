@@ -0,0 +1,3 @@
+UPDATE exchange_rates
+SET base_currency = $2, quote_currency = $3, rate = $4, effective_date = $5
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,6 @@
+INSERT INTO invoices (number, order_id, customer_id,
+    customer_name, customer_address, customer_tax_id,
+    issuer_name, issuer_address, issuer_tax_id,
+    issue_date, due_date, payment_terms, currency, subtotal, tax, total) VALUES
+($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+RETURNING id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO invoice_lines (invoice_id, position, service_id, title, quantity,
+    unit_price, discount, tax_rate, net_amount, tax_amount, notes) VALUES
+($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
\ No newline at end of file
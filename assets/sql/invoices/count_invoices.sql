@@ -0,0 +1,2 @@
+SELECT COUNT(*)
+FROM invoices i;
\ No newline at end of file
@@ -0,0 +1,5 @@
+SELECT i.id, i.number, i.order_id, i.customer_id,
+    i.customer_name, i.customer_address, i.customer_tax_id,
+    i.issuer_name, i.issuer_address, i.issuer_tax_id,
+    i.issue_date, i.due_date, i.payment_terms, i.currency, i.subtotal, i.tax, i.total
+FROM invoices i;
\ No newline at end of file
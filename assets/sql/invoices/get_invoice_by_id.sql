@@ -0,0 +1,6 @@
+SELECT i.id, i.number, i.order_id, i.customer_id,
+    i.customer_name, i.customer_address, i.customer_tax_id,
+    i.issuer_name, i.issuer_address, i.issuer_tax_id,
+    i.issue_date, i.due_date, i.payment_terms, i.currency, i.subtotal, i.tax, i.total
+FROM invoices i
+WHERE i.id = $1 AND ($2::INTEGER IS NULL OR i.customer_id = $2);
\ No newline at end of file
@@ -0,0 +1,5 @@
+SELECT l.position, l.service_id, l.title, l.quantity,
+    l.unit_price, l.discount, l.tax_rate, l.net_amount, l.tax_amount, l.notes
+FROM invoice_lines l
+WHERE l.invoice_id = $1
+ORDER BY l.position;
\ No newline at end of file
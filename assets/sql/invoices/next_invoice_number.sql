@@ -0,0 +1,3 @@
+UPDATE invoice_numbers
+SET last_number = last_number + 1
+RETURNING last_number;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP TABLE orders_to_services;
+DROP TABLE services;
+DROP TABLE orders;
+DROP TABLE customers;
\ No newline at end of file
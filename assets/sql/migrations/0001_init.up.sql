@@ -1,4 +1,4 @@
-CREATE TABLE customers (
+CREATE TABLE IF NOT EXISTS customers (
     id SERIAL PRIMARY KEY,
     company_name VARCHAR (128) NOT NULL,
     company_address VARCHAR (256) NOT NULL,
@@ -7,20 +7,20 @@ CREATE TABLE customers (
     phone_number CHAR(14) UNIQUE NOT NULL
 );
 
-CREATE TABLE orders (
+CREATE TABLE IF NOT EXISTS orders (
     id SERIAL PRIMARY KEY,
     customer_id SERIAL REFERENCES customers ON DELETE CASCADE,
     contract_date DATE NOT NULL DEFAULT CURRENT_DATE
 );
 
-CREATE TABLE services (
+CREATE TABLE IF NOT EXISTS services (
     id SERIAL PRIMARY KEY,
     title VARCHAR (256) UNIQUE NOT NULL,
     service_description VARCHAR (512) NOT NULL,
-    price DECIMAL (9, 2) NOT NULL 
+    price DECIMAL (9, 2) NOT NULL
 );
 
-CREATE TABLE orders_to_services (
+CREATE TABLE IF NOT EXISTS orders_to_services (
     order_id SERIAL REFERENCES orders,
     service_id SERIAL REFERENCES services,
     PRIMARY KEY (order_id, service_id)
@@ -0,0 +1 @@
+ALTER TABLE orders_to_services DROP COLUMN quantity;
\ No newline at end of file
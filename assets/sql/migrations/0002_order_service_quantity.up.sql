@@ -0,0 +1,2 @@
+ALTER TABLE orders_to_services
+    ADD COLUMN IF NOT EXISTS quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE api_keys
+    DROP COLUMN customer_id,
+    DROP COLUMN roles;
\ No newline at end of file
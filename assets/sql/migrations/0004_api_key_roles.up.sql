@@ -0,0 +1,6 @@
+-- The keys created before the roles were introduced keep their full access.
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS roles TEXT[] NOT NULL DEFAULT '{admin}',
+    ADD COLUMN IF NOT EXISTS customer_id INTEGER REFERENCES customers ON DELETE CASCADE;
+
+ALTER TABLE api_keys ALTER COLUMN roles SET DEFAULT '{}';
\ No newline at end of file
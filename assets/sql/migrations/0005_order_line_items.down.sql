@@ -0,0 +1,26 @@
+-- The lines repeating a service in the same order are merged into the first one.
+UPDATE orders_to_services os
+SET quantity = merged.quantity
+FROM (
+    SELECT MIN(id) AS id, SUM(quantity) AS quantity
+    FROM orders_to_services
+    GROUP BY order_id, service_id
+) merged
+WHERE os.id = merged.id;
+
+DELETE FROM orders_to_services os
+WHERE os.id NOT IN (
+    SELECT MIN(id)
+    FROM orders_to_services
+    GROUP BY order_id, service_id
+);
+
+DROP INDEX IF EXISTS orders_to_services_order_id_idx;
+
+ALTER TABLE orders_to_services
+    DROP COLUMN notes,
+    DROP COLUMN discount,
+    DROP COLUMN unit_price,
+    DROP COLUMN id;
+
+ALTER TABLE orders_to_services ADD PRIMARY KEY (order_id, service_id);
@@ -0,0 +1,21 @@
+-- A service may now appear in several lines of the same order.
+ALTER TABLE orders_to_services DROP CONSTRAINT IF EXISTS orders_to_services_pkey;
+
+ALTER TABLE orders_to_services
+    ADD COLUMN IF NOT EXISTS id SERIAL PRIMARY KEY,
+    ADD COLUMN IF NOT EXISTS unit_price DECIMAL (9, 2),
+    ADD COLUMN IF NOT EXISTS discount DECIMAL (5, 2) NOT NULL DEFAULT 0
+        CHECK (discount >= 0 AND discount <= 100),
+    ADD COLUMN IF NOT EXISTS notes VARCHAR (512) NOT NULL DEFAULT '';
+
+-- The prices the existing lines were billed at are unknown, so the current ones are taken.
+UPDATE orders_to_services os
+SET unit_price = s.price
+FROM services s
+WHERE os.service_id = s.id AND os.unit_price IS NULL;
+
+ALTER TABLE orders_to_services
+    ALTER COLUMN unit_price SET NOT NULL,
+    ADD CONSTRAINT orders_to_services_unit_price_check CHECK (unit_price >= 0);
+
+CREATE INDEX IF NOT EXISTS orders_to_services_order_id_idx ON orders_to_services (order_id);
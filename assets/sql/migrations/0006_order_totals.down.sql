@@ -0,0 +1,9 @@
+DROP VIEW IF EXISTS order_line_amounts;
+
+ALTER TABLE orders_to_services DROP COLUMN tax_rate;
+
+DROP TABLE IF EXISTS tax_rates;
+
+ALTER TABLE customers DROP COLUMN jurisdiction;
+
+ALTER TABLE services DROP COLUMN category;
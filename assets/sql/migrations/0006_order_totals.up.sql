@@ -0,0 +1,28 @@
+ALTER TABLE services ADD COLUMN IF NOT EXISTS category VARCHAR (64) NOT NULL DEFAULT '';
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS jurisdiction VARCHAR (64) NOT NULL DEFAULT '';
+
+-- The empty category or jurisdiction matches any.
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id SERIAL PRIMARY KEY,
+    category VARCHAR (64) NOT NULL DEFAULT '',
+    jurisdiction VARCHAR (64) NOT NULL DEFAULT '',
+    rate DECIMAL (6, 3) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    UNIQUE (category, jurisdiction)
+);
+
+-- The existing lines were billed without taxes.
+ALTER TABLE orders_to_services
+    ADD COLUMN IF NOT EXISTS tax_rate DECIMAL (6, 3) NOT NULL DEFAULT 0
+        CHECK (tax_rate >= 0 AND tax_rate <= 100);
+
+-- The discounts and the taxes are rounded to a cent for every line
+-- the same way repo.ComputeTotals does.
+CREATE OR REPLACE VIEW order_line_amounts AS
+SELECT os.id, os.order_id, line.net_amount,
+    ROUND(line.net_amount * os.tax_rate / 100, 2) AS tax_amount
+FROM orders_to_services os
+CROSS JOIN LATERAL (
+    SELECT os.quantity * os.unit_price
+        - ROUND(os.quantity * os.unit_price * os.discount / 100, 2) AS net_amount
+) line;
@@ -0,0 +1,19 @@
+DROP FUNCTION IF EXISTS exchange(DECIMAL, CHAR, CHAR, DATE);
+
+-- The columns can't be dropped from the view, so it's created anew.
+DROP VIEW IF EXISTS order_line_amounts;
+
+CREATE VIEW order_line_amounts AS
+SELECT os.id, os.order_id, line.net_amount,
+    ROUND(line.net_amount * os.tax_rate / 100, 2) AS tax_amount
+FROM orders_to_services os
+CROSS JOIN LATERAL (
+    SELECT os.quantity * os.unit_price
+        - ROUND(os.quantity * os.unit_price * os.discount / 100, 2) AS net_amount
+) line;
+
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE orders_to_services DROP COLUMN currency;
+
+ALTER TABLE services DROP COLUMN currency;
@@ -0,0 +1,52 @@
+-- The prices stored before the currencies were introduced are in roubles.
+ALTER TABLE services ADD COLUMN IF NOT EXISTS currency CHAR (3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE services ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE orders_to_services ADD COLUMN IF NOT EXISTS currency CHAR (3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE orders_to_services ALTER COLUMN currency DROP DEFAULT;
+
+-- A unit of the base currency costs the rate in the quote currency
+-- from the effective date until the next rate of the same currencies.
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id SERIAL PRIMARY KEY,
+    base_currency CHAR (3) NOT NULL,
+    quote_currency CHAR (3) NOT NULL,
+    rate DECIMAL (18, 8) NOT NULL CHECK (rate > 0),
+    effective_date DATE NOT NULL,
+    CONSTRAINT exchange_rates_currencies_check CHECK (quote_currency <> base_currency),
+    CONSTRAINT exchange_rates_currencies_date_key UNIQUE (base_currency, quote_currency, effective_date)
+);
+
+CREATE OR REPLACE VIEW order_line_amounts AS
+SELECT os.id, os.order_id, line.net_amount,
+    ROUND(line.net_amount * os.tax_rate / 100, 2) AS tax_amount,
+    os.currency
+FROM orders_to_services os
+CROSS JOIN LATERAL (
+    SELECT os.quantity * os.unit_price
+        - ROUND(os.quantity * os.unit_price * os.discount / 100, 2) AS net_amount
+) line;
+
+-- exchange converts the amount to the currency at the latest rate effective on the date
+-- and rounds the result to a cent. The inverse rate is used if it's more recent
+-- than the direct one. It returns NULL if there is no rate at all.
+CREATE OR REPLACE FUNCTION exchange(amount DECIMAL, from_currency CHAR (3), to_currency CHAR (3), on_date DATE)
+RETURNS DECIMAL
+LANGUAGE SQL STABLE
+AS $$
+    SELECT CASE
+        WHEN from_currency = to_currency THEN amount
+        ELSE (
+            SELECT CASE
+                WHEN er.base_currency = from_currency THEN ROUND(amount * er.rate, 2)
+                ELSE ROUND(amount / er.rate, 2)
+            END
+            FROM exchange_rates er
+            WHERE er.effective_date <= on_date
+            AND (er.base_currency = from_currency AND er.quote_currency = to_currency
+                OR er.base_currency = to_currency AND er.quote_currency = from_currency)
+            ORDER BY er.effective_date DESC, er.base_currency = from_currency DESC
+            LIMIT 1
+        )
+    END
+$$;
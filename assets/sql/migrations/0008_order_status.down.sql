@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS order_status_history;
+
+ALTER TABLE orders DROP COLUMN status;
@@ -0,0 +1,18 @@
+-- The existing orders stay drafts, so they can still be changed.
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS status VARCHAR (16) NOT NULL DEFAULT 'draft'
+        CONSTRAINT orders_status_check
+        CHECK (status IN ('draft', 'confirmed', 'in_progress', 'completed', 'cancelled'));
+
+-- The history is deleted along with the order.
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders ON DELETE CASCADE,
+    from_status VARCHAR (16) NOT NULL,
+    to_status VARCHAR (16) NOT NULL,
+    changed_by TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx
+    ON order_status_history (order_id);
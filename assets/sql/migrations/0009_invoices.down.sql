@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS invoice_lines;
+
+DROP TABLE IF EXISTS invoices;
+
+DROP FUNCTION IF EXISTS forbid_invoice_changes();
+
+DROP TABLE IF EXISTS invoice_numbers;
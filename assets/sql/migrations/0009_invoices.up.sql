@@ -0,0 +1,69 @@
+-- The invoices are numbered without gaps, so the numbers are taken from a counter
+-- locked until the end of the issuing transaction rather than from a sequence.
+CREATE TABLE IF NOT EXISTS invoice_numbers (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_number INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO invoice_numbers DEFAULT VALUES ON CONFLICT DO NOTHING;
+
+-- The parties, the lines and the totals are copied when the invoice is issued,
+-- so the later changes of the customer or the order don't affect it.
+-- An order is invoiced once and can't be deleted afterwards.
+CREATE TABLE IF NOT EXISTS invoices (
+    id SERIAL PRIMARY KEY,
+    number INTEGER NOT NULL UNIQUE,
+    order_id INTEGER NOT NULL UNIQUE REFERENCES orders,
+    customer_id INTEGER NOT NULL REFERENCES customers,
+    customer_name VARCHAR (128) NOT NULL,
+    customer_address VARCHAR (256) NOT NULL,
+    customer_tax_id VARCHAR (12) NOT NULL,
+    issuer_name VARCHAR (128) NOT NULL DEFAULT '',
+    issuer_address VARCHAR (256) NOT NULL DEFAULT '',
+    issuer_tax_id VARCHAR (12) NOT NULL DEFAULT '',
+    issue_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    due_date DATE NOT NULL,
+    payment_terms VARCHAR (256) NOT NULL DEFAULT '',
+    currency CHAR (3) NOT NULL,
+    subtotal DECIMAL (15, 2) NOT NULL,
+    tax DECIMAL (15, 2) NOT NULL,
+    total DECIMAL (15, 2) NOT NULL,
+    CONSTRAINT invoices_due_date_check CHECK (due_date >= issue_date)
+);
+
+CREATE INDEX IF NOT EXISTS invoices_customer_id_idx ON invoices (customer_id);
+
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices,
+    position INTEGER NOT NULL,
+    service_id INTEGER NOT NULL,
+    title VARCHAR (256) NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_price DECIMAL (9, 2) NOT NULL,
+    discount DECIMAL (5, 2) NOT NULL,
+    tax_rate DECIMAL (6, 3) NOT NULL,
+    net_amount DECIMAL (15, 2) NOT NULL,
+    tax_amount DECIMAL (15, 2) NOT NULL,
+    notes VARCHAR (512) NOT NULL DEFAULT '',
+    UNIQUE (invoice_id, position)
+);
+
+CREATE OR REPLACE FUNCTION forbid_invoice_changes() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'the issued invoices can''t be changed'
+        USING ERRCODE = 'restrict_violation';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS invoices_immutable ON invoices;
+
+CREATE TRIGGER invoices_immutable
+BEFORE UPDATE OR DELETE ON invoices
+FOR EACH ROW EXECUTE PROCEDURE forbid_invoice_changes();
+
+DROP TRIGGER IF EXISTS invoice_lines_immutable ON invoice_lines;
+
+CREATE TRIGGER invoice_lines_immutable
+BEFORE UPDATE OR DELETE ON invoice_lines
+FOR EACH ROW EXECUTE PROCEDURE forbid_invoice_changes();
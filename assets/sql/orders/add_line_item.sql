@@ -0,0 +1,21 @@
+WITH os AS (
+    INSERT INTO orders_to_services (order_id, service_id, quantity, unit_price, currency, discount, tax_rate, notes)
+    SELECT o.id, s.id, $3::INTEGER, s.price, s.currency, $4::DECIMAL, COALESCE((
+        -- The most specific rate applies, the category being more specific than the jurisdiction.
+        SELECT t.rate
+        FROM tax_rates t
+        WHERE t.category IN (s.category, '') AND t.jurisdiction IN (c.jurisdiction, '')
+        ORDER BY t.category = '', t.jurisdiction = ''
+        LIMIT 1
+    ), 0), $5::VARCHAR
+    FROM orders o
+    INNER JOIN customers c
+    ON o.customer_id = c.id
+    CROSS JOIN services s
+    WHERE o.id = $1 AND s.id = $2 AND ($6::INTEGER IS NULL OR o.customer_id = $6)
+    RETURNING *
+)
+SELECT os.id, os.order_id, os.service_id, s.title, os.quantity, os.unit_price, os.currency, os.discount, os.tax_rate, os.notes
+FROM os
+INNER JOIN services s
+ON os.service_id = s.id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO orders (customer_id, contract_date) VALUES
+($1, $2)
+RETURNING id, customer_id, contract_date, status;
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO order_status_history (order_id, from_status, to_status, changed_by) VALUES
+($1, $2, $3, $4)
+RETURNING id, order_id, from_status, to_status, changed_by, changed_at;
\ No newline at end of file
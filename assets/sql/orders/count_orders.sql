@@ -0,0 +1,2 @@
+SELECT COUNT(*)
+FROM orders o;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DELETE FROM orders_to_services os
+USING orders o
+WHERE os.order_id = o.id AND os.order_id = $1 AND os.id = $2
+AND ($3::INTEGER IS NULL OR o.customer_id = $3);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DELETE FROM orders_to_services os
+USING orders o
+WHERE os.order_id = o.id AND os.order_id = $1
+AND ($2::INTEGER IS NULL OR o.customer_id = $2);
\ No newline at end of file
@@ -0,0 +1,8 @@
+SELECT os.id, os.order_id, os.service_id, s.title, os.quantity, os.unit_price, os.currency, os.discount, os.tax_rate, os.notes
+FROM orders o
+INNER JOIN orders_to_services os
+ON o.id = os.order_id
+INNER JOIN services s
+ON os.service_id = s.id
+WHERE o.id = $1 AND ($2::INTEGER IS NULL OR o.customer_id = $2)
+ORDER BY os.id;
\ No newline at end of file
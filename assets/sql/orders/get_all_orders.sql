@@ -0,0 +1,2 @@
+SELECT o.id, o.customer_id, o.contract_date, o.status
+FROM orders o;
\ No newline at end of file
@@ -0,0 +1,11 @@
+SELECT o.id, o.customer_id, o.contract_date, o.status,
+    COALESCE(MIN(a.currency), '') AS currency,
+    COUNT(DISTINCT a.currency) AS currencies,
+    COALESCE(SUM(a.net_amount), 0) AS subtotal,
+    COALESCE(SUM(a.tax_amount), 0) AS tax,
+    COALESCE(SUM(a.net_amount + a.tax_amount), 0) AS total
+FROM orders o
+LEFT JOIN order_line_amounts a
+ON o.id = a.order_id
+WHERE o.id = $1 AND ($2::INTEGER IS NULL OR o.customer_id = $2)
+GROUP BY o.id;
\ No newline at end of file
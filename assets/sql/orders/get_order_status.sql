@@ -0,0 +1,3 @@
+SELECT o.status
+FROM orders o
+WHERE o.id = $1 AND ($2::INTEGER IS NULL OR o.customer_id = $2);
\ No newline at end of file
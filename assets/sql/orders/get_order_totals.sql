@@ -0,0 +1,15 @@
+SELECT t.subtotal, t.tax, t.subtotal + t.tax AS total, COALESCE(t.missing, '')
+FROM orders o
+CROSS JOIN LATERAL (
+    SELECT COALESCE(SUM(x.net_amount), 0) AS subtotal,
+        COALESCE(SUM(x.tax_amount), 0) AS tax,
+        -- The currencies of the lines that can't be converted.
+        STRING_AGG(DISTINCT a.currency::TEXT, ', ') FILTER (WHERE x.net_amount IS NULL) AS missing
+    FROM order_line_amounts a
+    CROSS JOIN LATERAL (
+        SELECT exchange(a.net_amount, a.currency, $2, o.contract_date) AS net_amount,
+            exchange(a.tax_amount, a.currency, $2, o.contract_date) AS tax_amount
+    ) x
+    WHERE a.order_id = o.id
+) t
+WHERE o.id = $1 AND ($3::INTEGER IS NULL OR o.customer_id = $3);
\ No newline at end of file
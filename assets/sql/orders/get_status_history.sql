@@ -0,0 +1,4 @@
+SELECT h.id, h.order_id, h.from_status, h.to_status, h.changed_by, h.changed_at
+FROM order_status_history h
+WHERE h.order_id = $1
+ORDER BY h.changed_at, h.id;
\ No newline at end of file
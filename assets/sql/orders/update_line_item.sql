@@ -0,0 +1,6 @@
+UPDATE orders_to_services os
+SET quantity = $3, discount = $4, notes = $5
+FROM orders o, services s
+WHERE os.order_id = o.id AND os.service_id = s.id AND os.order_id = $1 AND os.id = $2
+AND ($6::INTEGER IS NULL OR o.customer_id = $6)
+RETURNING os.id, os.order_id, os.service_id, s.title, os.quantity, os.unit_price, os.currency, os.discount, os.tax_rate, os.notes;
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE orders
+SET customer_id = $2, contract_date = $3
+WHERE id = $1 AND ($4::INTEGER IS NULL OR customer_id = $4 AND $2 = $4);
\ No newline at end of file
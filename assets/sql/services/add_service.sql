@@ -0,0 +1,3 @@
+INSERT INTO services (title, service_description, category, price, currency) VALUES
+($1, $2, $3, $4, $5)
+RETURNING id, title, service_description, category, price, currency;
\ No newline at end of file
@@ -0,0 +1,2 @@
+SELECT COUNT(*)
+FROM services s;
\ No newline at end of file
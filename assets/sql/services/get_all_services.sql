@@ -0,0 +1,2 @@
+SELECT s.id, s.title, s.service_description, s.category, s.price, s.currency
+FROM services s;
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT s.id, s.title, s.service_description, s.category, s.price, s.currency
+FROM services s
+WHERE s.id = $1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE services
+SET title = $2, service_description = $3, category = $4, price = $5, currency = $6
+WHERE id = $1;
\ No newline at end of file
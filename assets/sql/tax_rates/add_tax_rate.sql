@@ -0,0 +1,3 @@
+INSERT INTO tax_rates (category, jurisdiction, rate) VALUES
+($1, $2, $3)
+RETURNING id, category, jurisdiction, rate;
\ No newline at end of file
@@ -0,0 +1,2 @@
+SELECT COUNT(*)
+FROM tax_rates t;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DELETE FROM tax_rates t
+WHERE t.id = $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+SELECT t.id, t.category, t.jurisdiction, t.rate
+FROM tax_rates t;
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT t.id, t.category, t.jurisdiction, t.rate
+FROM tax_rates t
+WHERE t.id = $1;
\ No newline at end of file
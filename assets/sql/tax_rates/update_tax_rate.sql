@@ -0,0 +1,3 @@
+UPDATE tax_rates
+SET category = $2, jurisdiction = $3, rate = $4
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// apiKeyPrefix makes the API keys recognizable, e.g. by the secret scanners.
+const apiKeyPrefix = "rak_"
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashAPIKey returns the hash of the API key stored instead of the key.
+// The keys are random, so a fast hash without salt is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,170 @@
+// Package auth authenticates the clients of the REST API
+// with the API keys and the JWT bearer tokens.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"restApp/repo"
+	"strings"
+)
+
+// Authentication methods.
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Roles of the principals. The admins may do anything, the sales manage
+// the customers and the orders, and the customers see their own data.
+const (
+	RoleAdmin    = "admin"
+	RoleSales    = "sales"
+	RoleCustomer = "customer"
+)
+
+// IsRole checks if the role with the specified name exists.
+func IsRole(name string) bool {
+	switch name {
+	case RoleAdmin, RoleSales, RoleCustomer:
+		return true
+	}
+
+	return false
+}
+
+// apiKeyHeader is the header carrying the API key.
+const apiKeyHeader = "X-API-Key"
+
+var (
+	// ErrNoCredentials is returned when the request carries neither an API key nor a token.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned when the API key or the token is invalid.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated client of the REST API.
+type Principal struct {
+	// Subject is the name of the API key or the subject of the token.
+	Subject string
+	// Method is the method the client is authenticated with.
+	Method string
+	// Roles are the roles granted to the client.
+	Roles []string
+	// CustomerID is the ID of the customer the client is limited to, 0 if it isn't limited.
+	CustomerID int64
+}
+
+// HasRole checks if the role is granted to the principal.
+func (principal *Principal) HasRole(role string) bool {
+	return hasRole(principal.Roles, role)
+}
+
+// hasRole checks if the role is among the granted ones.
+func hasRole(roles []string, role string) bool {
+	for _, granted := range roles {
+		if granted == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+type contextKey int
+
+const principalKey contextKey = iota
+
+// NewContext returns the context carrying the principal.
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// FromContext returns the principal the context carries.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok
+}
+
+// APIKeyStore looks up the active API keys by their hashes.
+type APIKeyStore interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (*repo.APIKey, error)
+}
+
+// Authenticator authenticates the requests with the API key in the X-API-Key header
+// or with the JWT in the Authorization header.
+type Authenticator struct {
+	keys     APIKeyStore
+	verifier *Verifier
+}
+
+// Authenticate returns the principal the request is made by.
+// It returns ErrNoCredentials or ErrInvalidCredentials if the request isn't authenticated
+// and the errors of the repository if the API key can't be checked.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return a.authenticateKey(r.Context(), key)
+	}
+
+	authorization := r.Header.Get("Authorization")
+
+	if authorization == "" {
+		return nil, ErrNoCredentials
+	}
+
+	scheme, token := authorization, ""
+
+	if i := strings.IndexByte(authorization, ' '); i >= 0 {
+		scheme, token = authorization[:i], strings.TrimSpace(authorization[i+1:])
+	}
+
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, fmt.Errorf("%w: unsupported authorization scheme", ErrInvalidCredentials)
+	}
+
+	if a.verifier == nil {
+		return nil, fmt.Errorf("%w: tokens aren't accepted", ErrInvalidCredentials)
+	}
+
+	claims, err := a.verifier.Verify(token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{
+		Subject:    claims.Subject,
+		Method:     MethodJWT,
+		Roles:      claims.Roles,
+		CustomerID: claims.CustomerID,
+	}
+
+	return principal, nil
+}
+
+func (a *Authenticator) authenticateKey(ctx context.Context, key string) (*Principal, error) {
+	stored, err := a.keys.GetAPIKeyByHash(ctx, HashAPIKey(key))
+
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{Subject: stored.Name, Method: MethodAPIKey, Roles: stored.Roles}
+
+	if stored.CustomerID != nil {
+		principal.CustomerID = *stored.CustomerID
+	}
+
+	return principal, nil
+}
+
+// NewAuthenticator creates a new authenticator. The tokens aren't accepted without the verifier.
+func NewAuthenticator(keys APIKeyStore, verifier *Verifier) *Authenticator {
+	return &Authenticator{keys, verifier}
+}
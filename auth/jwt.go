@@ -0,0 +1,395 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Signature algorithms of the tokens.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// minHMACKeySize is the minimum size of the HS256 key in bytes as RFC 7518 requires.
+const minHMACKeySize = 32
+
+// clockSkew is the difference of the clocks tolerated when checking the times of the token.
+const clockSkew = 30 * time.Second
+
+// Claims are the registered claims of the token the verifier checks
+// along with the private claims granting the roles and limiting the client to a customer.
+type Claims struct {
+	Subject    string   `json:"sub"`
+	Issuer     string   `json:"iss"`
+	Audience   audience `json:"aud"`
+	ExpiresAt  *float64 `json:"exp"`
+	NotBefore  *float64 `json:"nbf"`
+	Roles      []string `json:"roles"`
+	CustomerID int64    `json:"customer_id"`
+}
+
+// audience is the aud claim that is either a single string or an array of them.
+type audience []string
+
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var single string
+
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = audience{single}
+
+		return nil
+	}
+
+	var multiple []string
+
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("incorrect aud claim: %w", err)
+	}
+
+	*aud = multiple
+
+	return nil
+}
+
+// jwtHeader is the header of the token.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verificationKey is the key verifying the signatures of a single algorithm.
+type verificationKey struct {
+	id     string
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+func (key *verificationKey) verify(signed, signature []byte) bool {
+	switch key.alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.secret)
+		mac.Write(signed)
+
+		return hmac.Equal(mac.Sum(nil), signature)
+
+	case AlgRS256:
+		digest := sha256.Sum256(signed)
+
+		return rsa.VerifyPKCS1v15(key.public, crypto.SHA256, digest[:], signature) == nil
+	}
+
+	return false
+}
+
+// Verifier checks the signatures and the claims of the JWTs.
+// Only HS256 and RS256 tokens signed with one of the keys of the verifier are accepted.
+type Verifier struct {
+	keys     []*verificationKey
+	issuer   string
+	audience string
+}
+
+// AddHMACKey adds the HS256 key read from the file.
+func (v *Verifier) AddHMACKey(path string) error {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("couldn't read the JWT secret: %w", err)
+	}
+
+	secret := bytes.TrimRight(data, "\r\n")
+
+	if len(secret) < minHMACKeySize {
+		return fmt.Errorf("the JWT secret must be at least %d bytes long", minHMACKeySize)
+	}
+
+	v.keys = append(v.keys, &verificationKey{alg: AlgHS256, secret: secret})
+
+	return nil
+}
+
+// AddRSAKey adds the RS256 key read from the PEM file
+// containing a public key or a certificate.
+func (v *Verifier) AddRSAKey(path string) error {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("couldn't read the JWT public key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return fmt.Errorf("the JWT public key %s isn't in the PEM format", path)
+	}
+
+	var public interface{}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			public = cert.PublicKey
+		}
+
+	default:
+		return fmt.Errorf("unsupported PEM block of the JWT public key: %s", block.Type)
+	}
+
+	if err != nil {
+		return fmt.Errorf("couldn't parse the JWT public key: %w", err)
+	}
+
+	key, ok := public.(*rsa.PublicKey)
+
+	if !ok {
+		return fmt.Errorf("the JWT public key isn't an RSA key")
+	}
+
+	v.keys = append(v.keys, &verificationKey{alg: AlgRS256, public: key})
+
+	return nil
+}
+
+// jwk is a single key of the JWK set.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// AddJWKS adds the RSA and the symmetric keys of the JWK set read from the file.
+// The keys meant for encryption are skipped.
+func (v *Verifier) AddJWKS(path string) error {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("couldn't read the JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err = json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("couldn't parse the JWKS: %w", err)
+	}
+
+	added := 0
+
+	for i, entry := range set.Keys {
+		if entry.Use != "" && entry.Use != "sig" {
+			continue
+		}
+
+		key, err := entry.verificationKey()
+
+		if err != nil {
+			return fmt.Errorf("incorrect key %d of the JWKS: %w", i, err)
+		}
+
+		if key != nil {
+			v.keys = append(v.keys, key)
+			added++
+		}
+	}
+
+	if added == 0 {
+		return fmt.Errorf("the JWKS %s contains no supported keys", path)
+	}
+
+	return nil
+}
+
+// verificationKey returns the key or nil if the key isn't supported.
+func (entry *jwk) verificationKey() (*verificationKey, error) {
+	switch {
+	case entry.Kty == "RSA" && (entry.Alg == "" || entry.Alg == AlgRS256):
+		n, err := base64.RawURLEncoding.DecodeString(entry.N)
+
+		if err != nil {
+			return nil, fmt.Errorf("incorrect modulus: %w", err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(entry.E)
+
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("incorrect exponent")
+		}
+
+		public := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+
+		return &verificationKey{id: entry.Kid, alg: AlgRS256, public: public}, nil
+
+	case entry.Kty == "oct" && (entry.Alg == "" || entry.Alg == AlgHS256):
+		secret, err := base64.RawURLEncoding.DecodeString(entry.K)
+
+		if err != nil {
+			return nil, fmt.Errorf("incorrect key value: %w", err)
+		}
+
+		if len(secret) < minHMACKeySize {
+			return nil, fmt.Errorf("the key must be at least %d bytes long", minHMACKeySize)
+		}
+
+		return &verificationKey{id: entry.Kid, alg: AlgHS256, secret: secret}, nil
+	}
+
+	return nil, nil
+}
+
+// HasKeys checks if any keys are added to the verifier.
+func (v *Verifier) HasKeys() bool {
+	return len(v.keys) > 0
+}
+
+// Verify checks the signature of the token and its claims and returns the claims.
+// The token must have the subject and the expiration time.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header jwtHeader
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+
+	if !v.verifySignature(&header, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrInvalidCredentials)
+	}
+
+	claims := new(Claims)
+
+	if err = decodeSegment(parts[1], claims); err != nil {
+		return nil, err
+	}
+
+	if err = v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// verifySignature checks if any key with the algorithm of the token
+// and with its key ID, if both specify one, has signed the token.
+func (v *Verifier) verifySignature(header *jwtHeader, signed, signature []byte) bool {
+	for _, key := range v.keys {
+		if key.alg != header.Alg {
+			continue
+		}
+
+		if header.Kid != "" && key.id != "" && header.Kid != key.id {
+			continue
+		}
+
+		if key.verify(signed, signature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *Verifier) checkClaims(claims *Claims) error {
+	now := time.Now()
+
+	switch {
+	case claims.Subject == "":
+		return fmt.Errorf("%w: the token has no subject", ErrInvalidCredentials)
+
+	case claims.ExpiresAt == nil:
+		return fmt.Errorf("%w: the token has no expiration time", ErrInvalidCredentials)
+
+	case now.Add(-clockSkew).After(unixTime(*claims.ExpiresAt)):
+		return fmt.Errorf("%w: the token has expired", ErrInvalidCredentials)
+
+	case claims.NotBefore != nil && now.Add(clockSkew).Before(unixTime(*claims.NotBefore)):
+		return fmt.Errorf("%w: the token isn't valid yet", ErrInvalidCredentials)
+
+	case v.issuer != "" && claims.Issuer != v.issuer:
+		return fmt.Errorf("%w: the token is issued by an unknown issuer", ErrInvalidCredentials)
+
+	case claims.CustomerID < 0:
+		return fmt.Errorf("%w: the token has an incorrect customer ID", ErrInvalidCredentials)
+
+	// The customers without the customer ID would see the data of every customer.
+	case claims.CustomerID == 0 && hasRole(claims.Roles, RoleCustomer):
+		return fmt.Errorf("%w: the customer token has no customer ID", ErrInvalidCredentials)
+	}
+
+	if v.audience == "" {
+		return nil
+	}
+
+	for _, aud := range claims.Audience {
+		if aud == v.audience {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: the token is issued for another audience", ErrInvalidCredentials)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+
+	if err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	return nil
+}
+
+// unixTime converts the NumericDate of the claims to the time.
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
+
+// NewVerifier creates a new verifier without keys. The issuer and the audience
+// of the tokens are checked if specified.
+func NewVerifier(issuer, audience string) *Verifier {
+	return &Verifier{issuer: issuer, audience: audience}
+}
@@ -0,0 +1,410 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "restApp"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// testRSAKey is shared by the tests because generating the keys is slow.
+var testRSAKey, otherRSAKey = mustGenerateRSAKey(), mustGenerateRSAKey()
+
+func mustGenerateRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
+
+// signToken returns the token with the header and the claims signed with the key,
+// which is either an HMAC secret or an RSA private key.
+func signToken(t *testing.T, header, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+
+	encode := func(value interface{}) string {
+		data, err := json.Marshal(value)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(header) + "." + encode(claims)
+	var signature []byte
+
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns the claims any verifier of the tests accepts.
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "client",
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{RoleSales},
+	}
+}
+
+// writeFile writes the data to a file of the temporary directory of the test and returns its path.
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func publicKeyPEM(t *testing.T, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	data, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: data})
+}
+
+func TestVerify(t *testing.T) {
+	verifier := NewVerifier(testIssuer, testAudience)
+
+	if err := verifier.AddHMACKey(writeFile(t, "secret", append(testSecret, '\n'))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifier.AddRSAKey(writeFile(t, "key.pem", publicKeyPEM(t, testRSAKey))); err != nil {
+		t.Fatal(err)
+	}
+
+	hs256 := map[string]interface{}{"alg": AlgHS256, "typ": "JWT"}
+	rs256 := map[string]interface{}{"alg": AlgRS256, "typ": "JWT"}
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		header map[string]interface{}
+		key    interface{}
+		// change modifies the valid claims.
+		change func(claims map[string]interface{})
+		valid  bool
+	}{
+		{"HS256", hs256, testSecret, nil, true},
+		{"RS256", rs256, testRSAKey, nil, true},
+		{"HS256 with another secret", hs256, []byte("another secret of at least 32 bytes"), nil, false},
+		{"RS256 with another key", rs256, otherRSAKey, nil, false},
+		{"RS256 header with HMAC", rs256, testSecret, nil, false},
+		{"HS256 with the public key as the secret", hs256, publicKeyPEM(t, testRSAKey), nil, false},
+		{"none algorithm", map[string]interface{}{"alg": "none"}, nil, nil, false},
+		{"expired", hs256, testSecret, func(c map[string]interface{}) {
+			c["exp"] = now.Add(-time.Hour).Unix()
+		}, false},
+		{"expired within the clock skew", hs256, testSecret, func(c map[string]interface{}) {
+			c["exp"] = now.Add(-clockSkew / 2).Unix()
+		}, true},
+		{"no expiration time", hs256, testSecret, func(c map[string]interface{}) {
+			delete(c, "exp")
+		}, false},
+		{"not valid yet", hs256, testSecret, func(c map[string]interface{}) {
+			c["nbf"] = now.Add(time.Hour).Unix()
+		}, false},
+		{"valid since now", hs256, testSecret, func(c map[string]interface{}) {
+			c["nbf"] = now.Unix()
+		}, true},
+		{"no subject", hs256, testSecret, func(c map[string]interface{}) {
+			delete(c, "sub")
+		}, false},
+		{"unknown issuer", hs256, testSecret, func(c map[string]interface{}) {
+			c["iss"] = "https://other.example.com"
+		}, false},
+		{"another audience", hs256, testSecret, func(c map[string]interface{}) {
+			c["aud"] = "other"
+		}, false},
+		{"audience among several", hs256, testSecret, func(c map[string]interface{}) {
+			c["aud"] = []string{"other", testAudience}
+		}, true},
+		{"no audience", hs256, testSecret, func(c map[string]interface{}) {
+			delete(c, "aud")
+		}, false},
+		{"customer", hs256, testSecret, func(c map[string]interface{}) {
+			c["roles"] = []string{RoleCustomer}
+			c["customer_id"] = 42
+		}, true},
+		{"customer without customer ID", hs256, testSecret, func(c map[string]interface{}) {
+			c["roles"] = []string{RoleCustomer}
+		}, false},
+		{"customer with zero customer ID", hs256, testSecret, func(c map[string]interface{}) {
+			c["roles"] = []string{RoleSales, RoleCustomer}
+			c["customer_id"] = 0
+		}, false},
+		{"negative customer ID", hs256, testSecret, func(c map[string]interface{}) {
+			c["customer_id"] = -1
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := validClaims()
+
+			if test.change != nil {
+				test.change(claims)
+			}
+
+			got, err := verifier.Verify(signToken(t, test.header, claims, test.key))
+
+			if !test.valid {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("error = %v, want %v", err, ErrInvalidCredentials)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Subject != "client" {
+				t.Errorf("subject = %q, want %q", got.Subject, "client")
+			}
+		})
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	verifier := NewVerifier("", "")
+
+	if err := verifier.AddHMACKey(writeFile(t, "secret", testSecret)); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := signToken(t, map[string]interface{}{"alg": AlgHS256}, validClaims(), testSecret)
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"two segments", parts[0] + "." + parts[1]},
+		{"four segments", valid + ".x"},
+		{"header isn't base64", "!." + parts[1] + "." + parts[2]},
+		{"header isn't JSON", base64.RawURLEncoding.EncodeToString([]byte("{")) + "." + parts[1] + "." + parts[2]},
+		{"signature isn't base64", parts[0] + "." + parts[1] + ".!"},
+		{"payload changed", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`)) + "." + parts[2]},
+	}
+
+	for _, test := range tests {
+		if _, err := verifier.Verify(test.token); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, ErrInvalidCredentials)
+		}
+	}
+
+	// Without the issuer and the audience of the verifier any of them are accepted.
+	if _, err := verifier.Verify(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAddHMACKey(t *testing.T) {
+	verifier := NewVerifier("", "")
+
+	if err := verifier.AddHMACKey(writeFile(t, "secret", testSecret[:minHMACKeySize-1])); err == nil {
+		t.Error("the short secret is accepted")
+	}
+
+	if err := verifier.AddHMACKey(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("the missing file is accepted")
+	}
+
+	if verifier.HasKeys() {
+		t.Error("the verifier has keys after the errors")
+	}
+}
+
+func TestAddRSAKey(t *testing.T) {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "issuer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &testRSAKey.PublicKey, testRSAKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecPublic, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		valid bool
+	}{
+		{"PKIX", publicKeyPEM(t, testRSAKey), true},
+		{"PKCS #1", pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY",
+			Bytes: x509.MarshalPKCS1PublicKey(&testRSAKey.PublicKey)}), true},
+		{"certificate", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), true},
+		{"not PEM", []byte("key"), false},
+		{"private key", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(testRSAKey)}), false},
+		{"EC key", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecPublic}), false},
+		{"broken key", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("key")}), false},
+	}
+
+	token := signToken(t, map[string]interface{}{"alg": AlgRS256}, validClaims(), testRSAKey)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := NewVerifier("", "")
+			err := verifier.AddRSAKey(writeFile(t, "key.pem", test.data))
+
+			if !test.valid {
+				if err == nil {
+					t.Fatal("the key is accepted")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err = verifier.Verify(token); err != nil {
+				t.Errorf("the token isn't verified: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddJWKS(t *testing.T) {
+	encode := base64.RawURLEncoding.EncodeToString
+	rsaKey := map[string]interface{}{
+		"kty": "RSA", "kid": "rsa", "alg": AlgRS256, "use": "sig",
+		"n": encode(testRSAKey.N.Bytes()), "e": encode(big.NewInt(int64(testRSAKey.E)).Bytes()),
+	}
+	hmacKey := map[string]interface{}{"kty": "oct", "kid": "hmac", "k": encode(testSecret)}
+	encryptionKey := map[string]interface{}{
+		"kty": "RSA", "kid": "enc", "use": "enc",
+		"n": encode(otherRSAKey.N.Bytes()), "e": encode(big.NewInt(int64(otherRSAKey.E)).Bytes()),
+	}
+	ecKey := map[string]interface{}{"kty": "EC", "kid": "ec", "crv": "P-256", "x": "AA", "y": "AA"}
+
+	set := func(keys ...map[string]interface{}) []byte {
+		data, err := json.Marshal(map[string]interface{}{"keys": keys})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return data
+	}
+
+	verifier := NewVerifier("", "")
+
+	if err := verifier.AddJWKS(writeFile(t, "jwks.json", set(rsaKey, hmacKey, encryptionKey, ecKey))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]interface{}
+		key    interface{}
+		valid  bool
+	}{
+		{"RSA key by ID", map[string]interface{}{"alg": AlgRS256, "kid": "rsa"}, testRSAKey, true},
+		{"RSA key without ID", map[string]interface{}{"alg": AlgRS256}, testRSAKey, true},
+		{"HMAC key by ID", map[string]interface{}{"alg": AlgHS256, "kid": "hmac"}, testSecret, true},
+		{"unknown key ID", map[string]interface{}{"alg": AlgRS256, "kid": "other"}, testRSAKey, false},
+		{"key ID of another algorithm", map[string]interface{}{"alg": AlgRS256, "kid": "hmac"}, testRSAKey, false},
+		{"encryption key", map[string]interface{}{"alg": AlgRS256, "kid": "enc"}, otherRSAKey, false},
+	}
+
+	for _, test := range tests {
+		_, err := verifier.Verify(signToken(t, test.header, validClaims(), test.key))
+
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+
+		if !test.valid && !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, ErrInvalidCredentials)
+		}
+	}
+
+	invalid := []struct {
+		name string
+		data []byte
+	}{
+		{"not JSON", []byte("{")},
+		{"no supported keys", set(encryptionKey, ecKey)},
+		{"short HMAC key", set(map[string]interface{}{"kty": "oct", "k": encode(testSecret[:8])})},
+		{"broken modulus", set(map[string]interface{}{"kty": "RSA", "n": "!", "e": "AQAB"})},
+		{"broken exponent", set(map[string]interface{}{"kty": "RSA", "n": "AQAB", "e": ""})},
+	}
+
+	for _, test := range invalid {
+		if err := NewVerifier("", "").AddJWKS(writeFile(t, "jwks.json", test.data)); err == nil {
+			t.Errorf("%s: the JWKS is accepted", test.name)
+		}
+	}
+}
@@ -0,0 +1,557 @@
+// Package config loads the configuration of the application.
+//
+// The configuration is layered: the defaults are overridden by the YAML file,
+// the file is overridden by the environment variables and the environment variables
+// are overridden by the command line flags. Secrets can be read from files
+// so they don't have to be passed on the command line (e.g. Docker secrets).
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"restApp/logging"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix of the environment variables containing the settings.
+const envPrefix = "RESTAPP_"
+
+// redacted replaces the secrets in the printed configuration.
+const redacted = "<redacted>"
+
+// Config is the configuration of the application.
+type Config struct {
+	Database DatabaseConfig `yaml:"database"`
+	Server   ServerConfig   `yaml:"server"`
+	Log      LogConfig      `yaml:"log"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Invoice  InvoiceConfig  `yaml:"invoice"`
+}
+
+// DatabaseConfig contains the settings of the database connection.
+type DatabaseConfig struct {
+	// DSN is the connection string either as a URL or as key=value pairs. If it's set,
+	// the host, the name and the username are ignored, while the password and
+	// the SSL settings are added to the DSN unless it already contains them.
+	DSN          string `yaml:"dsn"`
+	Host         string `yaml:"host"`
+	Name         string `yaml:"name"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+	// SSLMode defaults to disable, or to the mode of the driver if the DSN is set.
+	SSLMode string `yaml:"sslmode"`
+	// SSLRootCert is the CA certificate to verify the server certificate with.
+	SSLRootCert string `yaml:"sslrootcert"`
+	// SSLCert and SSLKey are the client certificate and its private key.
+	SSLCert string `yaml:"sslcert"`
+	SSLKey  string `yaml:"sslkey"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// ConnectTimeout limits the time spent waiting for the database on start.
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+
+	Migrate      bool          `yaml:"migrate"`
+	TxIsolation  string        `yaml:"tx_isolation"`
+	TxRetries    int           `yaml:"tx_retries"`
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+}
+
+// ServerConfig contains the settings of the HTTP server.
+type ServerConfig struct {
+	Address         string        `yaml:"address"`
+	Port            string        `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// LogConfig contains the settings of the log.
+// Without the path the log is written to stdout only.
+type LogConfig struct {
+	Path           string        `yaml:"path"`
+	Level          string        `yaml:"level"`
+	MaxSize        int           `yaml:"max_size_mb"`
+	RotateInterval time.Duration `yaml:"rotate_interval"`
+	MaxBackups     int           `yaml:"max_backups"`
+	Compress       bool          `yaml:"compress"`
+}
+
+// AuthConfig contains the settings of the authentication of the REST API clients.
+// The API keys are always accepted, the tokens only if any of the keys verifying them is specified.
+type AuthConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	JWTSecretFile    string `yaml:"jwt_secret_file"`
+	JWTPublicKeyFile string `yaml:"jwt_public_key_file"`
+	JWKSFile         string `yaml:"jwks_file"`
+	JWTIssuer        string `yaml:"jwt_issuer"`
+	JWTAudience      string `yaml:"jwt_audience"`
+}
+
+// InvoiceConfig contains the details of the company issuing the invoices
+// and the number of days the invoices are due in unless the request specifies the due date.
+type InvoiceConfig struct {
+	Issuer        string `yaml:"issuer"`
+	IssuerAddress string `yaml:"issuer_address"`
+	IssuerTaxID   string `yaml:"issuer_tax_id"`
+	PaymentDays   int    `yaml:"payment_days"`
+}
+
+// Default returns the configuration used if no settings are specified.
+func Default() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+			TxIsolation:     "serializable",
+			TxRetries:       3,
+			QueryTimeout:    30 * time.Second,
+		},
+		Server: ServerConfig{
+			Port:            "80",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    60 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Log: LogConfig{
+			Path:       "/var/log/rest/sys.log",
+			Level:      "info",
+			MaxSize:    100,
+			MaxBackups: 10,
+			Compress:   true,
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+		},
+		Invoice: InvoiceConfig{
+			PaymentDays: 30,
+		},
+	}
+}
+
+// setting binds a field of the configuration
+// to a command line flag and an environment variable.
+type setting struct {
+	flag  string
+	env   string
+	value interface{}
+	usage string
+}
+
+func (cfg *Config) settings() []setting {
+	db := &cfg.Database
+	server := &cfg.Server
+	authn := &cfg.Auth
+	invoice := &cfg.Invoice
+
+	return []setting{
+		{"dsn", "DB_DSN", &db.DSN,
+			"A connection string of the database, overrides the db host, name and username"},
+		{"dbusername", "DB_USERNAME", &db.Username, "A username to access the database"},
+		{"dbpassword", "DB_PASSWORD", &db.Password, "A password to access the database"},
+		{"dbpassword-file", "DB_PASSWORD_FILE", &db.PasswordFile,
+			"A file containing the password to access the database"},
+		{"dbhost", "DB_HOST", &db.Host, "A host on which the DBMS is deployed"},
+		{"dbname", "DB_NAME", &db.Name, "A name of the database"},
+		{"dbsslmode", "DB_SSLMODE", &db.SSLMode,
+			"An SSL mode of the db connection: disable, require, verify-ca or verify-full"},
+		{"dbsslrootcert", "DB_SSLROOTCERT", &db.SSLRootCert,
+			"A file containing the CA certificate to verify the db server with"},
+		{"dbsslcert", "DB_SSLCERT", &db.SSLCert, "A file containing the client certificate for the db"},
+		{"dbsslkey", "DB_SSLKEY", &db.SSLKey,
+			"A file containing the private key of the client certificate for the db"},
+		{"db-max-open-conns", "DB_MAX_OPEN_CONNS", &db.MaxOpenConns,
+			"A maximum number of open db connections, 0 means no limit"},
+		{"db-max-idle-conns", "DB_MAX_IDLE_CONNS", &db.MaxIdleConns,
+			"A maximum number of idle db connections"},
+		{"db-conn-max-lifetime", "DB_CONN_MAX_LIFETIME", &db.ConnMaxLifetime,
+			"A maximum time a db connection may be reused for, 0 means no limit"},
+		{"db-conn-max-idle-time", "DB_CONN_MAX_IDLE_TIME", &db.ConnMaxIdleTime,
+			"A maximum time a db connection may be idle for, 0 means no limit"},
+		{"db-connect-timeout", "DB_CONNECT_TIMEOUT", &db.ConnectTimeout,
+			"A time limit for waiting for the database to become available on start"},
+
+		{"migrate", "MIGRATE", &db.Migrate,
+			"Apply the pending migrations of the database schema on start"},
+		{"tx-isolation", "TX_ISOLATION", &db.TxIsolation,
+			"An isolation level of the transactions: read-committed, repeatable-read or serializable"},
+		{"tx-retries", "TX_RETRIES", &db.TxRetries,
+			"A number of retries of the transactions failed due to concurrent ones"},
+		{"query-timeout", "QUERY_TIMEOUT", &db.QueryTimeout,
+			"A time limit for the database queries of a single request, 0 means no limit"},
+
+		{"address", "ADDRESS", &server.Address, "An address to listen on"},
+		{"port", "PORT", &server.Port, "A port to listen on"},
+		{"read-timeout", "READ_TIMEOUT", &server.ReadTimeout,
+			"A time limit for reading a request including its body"},
+		{"write-timeout", "WRITE_TIMEOUT", &server.WriteTimeout,
+			"A time limit for processing a request and writing the response"},
+		{"idle-timeout", "IDLE_TIMEOUT", &server.IdleTimeout,
+			"A time a keep-alive connection may wait for the next request"},
+		{"shutdown-timeout", "SHUTDOWN_TIMEOUT", &server.ShutdownTimeout,
+			"A time limit for finishing the requests in progress on shutdown"},
+
+		{"log", "LOG", &cfg.Log.Path, "A path to the log file, empty means stdout only"},
+		{"log-level", "LOG_LEVEL", &cfg.Log.Level, "A log level: debug, info, warn or error"},
+		{"log-max-size", "LOG_MAX_SIZE", &cfg.Log.MaxSize,
+			"A size in megabytes the log file is rotated after, 0 means no limit"},
+		{"log-rotate-interval", "LOG_ROTATE_INTERVAL", &cfg.Log.RotateInterval,
+			"A time the log file is rotated after, 0 means no limit"},
+		{"log-max-backups", "LOG_MAX_BACKUPS", &cfg.Log.MaxBackups,
+			"A number of the rotated log files kept, 0 means all of them"},
+		{"log-compress", "LOG_COMPRESS", &cfg.Log.Compress, "Compress the rotated log files with gzip"},
+
+		{"auth", "AUTH", &authn.Enabled, "Require the clients of the REST API to authenticate"},
+		{"jwt-secret-file", "JWT_SECRET_FILE", &authn.JWTSecretFile,
+			"A file containing the secret verifying the HS256 tokens"},
+		{"jwt-public-key-file", "JWT_PUBLIC_KEY_FILE", &authn.JWTPublicKeyFile,
+			"A PEM file containing the RSA public key verifying the RS256 tokens"},
+		{"jwks-file", "JWKS_FILE", &authn.JWKSFile, "A JWKS file containing the keys verifying the tokens"},
+		{"jwt-issuer", "JWT_ISSUER", &authn.JWTIssuer, "An issuer the tokens must be issued by"},
+		{"jwt-audience", "JWT_AUDIENCE", &authn.JWTAudience, "An audience the tokens must be issued for"},
+
+		{"invoice-issuer", "INVOICE_ISSUER", &invoice.Issuer, "A name of the company issuing the invoices"},
+		{"invoice-issuer-address", "INVOICE_ISSUER_ADDRESS", &invoice.IssuerAddress,
+			"An address of the company issuing the invoices"},
+		{"invoice-issuer-tax-id", "INVOICE_ISSUER_TAX_ID", &invoice.IssuerTaxID,
+			"A tax ID of the company issuing the invoices"},
+		{"invoice-payment-days", "INVOICE_PAYMENT_DAYS", &invoice.PaymentDays,
+			"A number of days the invoices are due in by default"},
+	}
+}
+
+// Options are the settings of the loader itself specified on the command line.
+type Options struct {
+	// Path is the path to the configuration file.
+	Path string
+	// Print means the configuration should be printed rather than used.
+	Print bool
+	// Args are the command line arguments remaining after the flags.
+	Args []string
+}
+
+// Load loads the configuration from the YAML file, the environment variables
+// and the command line arguments. The file is optional if its path
+// isn't specified explicitly, defaultPath is used then.
+func Load(flags *flag.FlagSet, args []string, defaultPath string) (*Config, *Options, error) {
+	cfg := Default()
+	opts := new(Options)
+
+	flags.StringVar(&opts.Path, "config", "", fmt.Sprintf(
+		"A path to the YAML configuration file (default %q), also %sCONFIG", defaultPath, envPrefix))
+	flags.BoolVar(&opts.Print, "print-config", false,
+		"Print the configuration with the secrets redacted and exit")
+
+	for _, s := range cfg.settings() {
+		flags.Var(value{s.value}, s.flag, fmt.Sprintf("%s, also %s%s", s.usage, envPrefix, s.env))
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	opts.Args = flags.Args()
+
+	// The flags are parsed into the defaults to find out the configuration file.
+	// They are applied again on top of the file and the environment variables.
+	specified := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		specified[f.Name] = f.Value.String()
+	})
+
+	cfg = Default()
+	path := opts.Path
+
+	if path == "" {
+		path = os.Getenv(envPrefix + "CONFIG")
+	}
+
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, nil, err
+		}
+	} else if err := cfg.readFile(defaultPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	for _, s := range cfg.settings() {
+		env, ok := os.LookupEnv(envPrefix + s.env)
+
+		if !ok {
+			continue
+		}
+
+		if err := (value{s.value}).Set(env); err != nil {
+			return nil, nil, fmt.Errorf("incorrect value of %s%s: %w", envPrefix, s.env, err)
+		}
+	}
+
+	for _, s := range cfg.settings() {
+		if flagValue, ok := specified[s.flag]; ok {
+			// The value has already been parsed once, so it's correct.
+			(value{s.value}).Set(flagValue)
+		}
+	}
+
+	if err := cfg.readSecrets(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, opts, nil
+}
+
+func (cfg *Config) readFile(path string) error {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return fmt.Errorf("couldn't open the configuration file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	if err = decoder.Decode(cfg); err != nil && err != io.EOF {
+		return fmt.Errorf("couldn't parse the configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readSecrets reads the secrets specified as files.
+func (cfg *Config) readSecrets() error {
+	if cfg.Database.PasswordFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(cfg.Database.PasswordFile)
+
+	if err != nil {
+		return fmt.Errorf("couldn't read the db password: %w", err)
+	}
+
+	cfg.Database.Password = strings.TrimRight(string(data), "\r\n")
+
+	return nil
+}
+
+func (cfg *Config) validate() error {
+	if _, err := logging.ParseLevel(cfg.Log.Level); err != nil {
+		return err
+	}
+
+	switch cfg.Database.SSLMode {
+	case "", "disable", "require", "verify-ca", "verify-full":
+
+	default:
+		return fmt.Errorf("unknown db SSL mode: %s", cfg.Database.SSLMode)
+	}
+
+	if cfg.Database.MaxOpenConns < 0 || cfg.Database.MaxIdleConns < 0 {
+		return fmt.Errorf("the numbers of db connections must not be negative")
+	}
+
+	if (cfg.Database.SSLCert == "") != (cfg.Database.SSLKey == "") {
+		return fmt.Errorf("the db client certificate and its key must be specified together")
+	}
+
+	if cfg.Database.TxRetries < 0 {
+		return fmt.Errorf("the number of transaction retries must not be negative")
+	}
+
+	if cfg.Log.MaxSize < 0 || cfg.Log.RotateInterval < 0 || cfg.Log.MaxBackups < 0 {
+		return fmt.Errorf("the log rotation settings must not be negative")
+	}
+
+	if cfg.Invoice.PaymentDays < 0 {
+		return fmt.Errorf("the number of invoice payment days must not be negative")
+	}
+
+	return nil
+}
+
+// ConnectionString returns the connection string of the database.
+func (db *DatabaseConfig) ConnectionString() string {
+	if db.DSN != "" {
+		return db.completeDSN()
+	}
+
+	sslMode := db.SSLMode
+
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	query := url.Values{"sslmode": {sslMode}}
+
+	if db.SSLRootCert != "" {
+		query.Set("sslrootcert", db.SSLRootCert)
+	}
+
+	if db.SSLCert != "" {
+		query.Set("sslcert", db.SSLCert)
+		query.Set("sslkey", db.SSLKey)
+	}
+
+	dsn := &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(db.Username, db.Password),
+		Host:     db.Host,
+		Path:     "/" + db.Name,
+		RawQuery: query.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// completeDSN adds the password and the SSL settings to the DSN
+// unless it already contains them. Without the SSL mode in either of them
+// the default mode of the driver applies.
+func (db *DatabaseConfig) completeDSN() string {
+	settings := [][2]string{
+		{"sslmode", db.SSLMode},
+		{"sslrootcert", db.SSLRootCert},
+		{"sslcert", db.SSLCert},
+		{"sslkey", db.SSLKey},
+	}
+
+	if dsn, err := url.Parse(db.DSN); err == nil && dsn.Scheme != "" {
+		if _, ok := dsn.User.Password(); !ok && db.Password != "" {
+			dsn.User = url.UserPassword(dsn.User.Username(), db.Password)
+		}
+
+		query := dsn.Query()
+
+		for _, setting := range settings {
+			if _, ok := query[setting[0]]; !ok && setting[1] != "" {
+				query.Set(setting[0], setting[1])
+			}
+		}
+
+		dsn.RawQuery = query.Encode()
+
+		return dsn.String()
+	}
+
+	dsn := db.DSN
+	keys := dsnKeys(dsn)
+	settings = append([][2]string{{"password", db.Password}}, settings...)
+
+	for _, setting := range settings {
+		if !keys[setting[0]] && setting[1] != "" {
+			dsn += fmt.Sprintf(" %s=%s", setting[0], quoteDSNValue(setting[1]))
+		}
+	}
+
+	return dsn
+}
+
+// dsnKeys returns the keys of the DSN in the key=value form.
+// The values may be quoted with single quotes and contain escaped characters.
+func dsnKeys(dsn string) map[string]bool {
+	keys := make(map[string]bool)
+	r := []rune(dsn)
+
+	for i := 0; i < len(r); {
+		for i < len(r) && unicode.IsSpace(r[i]) {
+			i++
+		}
+
+		start := i
+
+		for i < len(r) && r[i] != '=' && !unicode.IsSpace(r[i]) {
+			i++
+		}
+
+		if key := string(r[start:i]); key != "" {
+			keys[key] = true
+		}
+
+		for i < len(r) && unicode.IsSpace(r[i]) {
+			i++
+		}
+
+		if i < len(r) && r[i] == '=' {
+			i++
+		}
+
+		for i < len(r) && unicode.IsSpace(r[i]) {
+			i++
+		}
+
+		if i < len(r) && r[i] == '\'' {
+			for i++; i < len(r) && r[i] != '\''; i++ {
+				if r[i] == '\\' {
+					i++
+				}
+			}
+
+			i++
+
+			continue
+		}
+
+		for i < len(r) && !unicode.IsSpace(r[i]) {
+			if r[i] == '\\' {
+				i++
+			}
+
+			i++
+		}
+	}
+
+	return keys
+}
+
+// quoteDSNValue quotes the value of the DSN in the key=value form.
+func quoteDSNValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return "'" + value + "'"
+}
+
+// Print writes the configuration to the writer in YAML with the secrets redacted.
+func (cfg *Config) Print(w io.Writer) error {
+	printed := *cfg
+
+	if printed.Database.Password != "" {
+		printed.Database.Password = redacted
+	}
+
+	if dsn, err := url.Parse(printed.Database.DSN); err == nil && dsn.Scheme != "" {
+		printed.Database.DSN = dsn.Redacted()
+	} else if strings.Contains(printed.Database.DSN, "password") {
+		// The password can't be found reliably in the key=value form.
+		printed.Database.DSN = redacted
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&printed); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setenv sets the environment variable for the duration of the test.
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+	previous, ok := os.LookupEnv(key)
+
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+// writeFile writes the data to the file in the temporary directory of the test.
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func load(t *testing.T, args ...string) (*Config, *Options) {
+	t.Helper()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	cfg, opts, err := Load(flags, args, filepath.Join(t.TempDir(), "missing.yml"))
+
+	if err != nil {
+		t.Fatalf("Load(%q): %v", args, err)
+	}
+
+	return cfg, opts
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, opts := load(t)
+
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("config = %+v, want the defaults %+v", cfg, Default())
+	}
+
+	if opts.Print || opts.Path != "" {
+		t.Errorf("options = %+v, want none", opts)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yml", `
+database:
+  host: file-host
+  name: file-name
+  username: file-user
+  tx_retries: 5
+server:
+  port: "8000"
+  read_timeout: 5s
+log:
+  level: debug
+`)
+	setenv(t, envPrefix+"DB_NAME", "env-name")
+	setenv(t, envPrefix+"DB_USERNAME", "env-user")
+	setenv(t, envPrefix+"READ_TIMEOUT", "7s")
+
+	cfg, opts := load(t, "--config", path, "--dbusername", "flag-user", "--port", "9000", "migrate")
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"default", cfg.Server.WriteTimeout, Default().Server.WriteTimeout},
+		{"file", cfg.Database.Host, "file-host"},
+		{"file", cfg.Database.TxRetries, 5},
+		{"file", cfg.Log.Level, "debug"},
+		{"env over file", cfg.Database.Name, "env-name"},
+		{"env over file", cfg.Server.ReadTimeout, 7 * time.Second},
+		{"flag over env", cfg.Database.Username, "flag-user"},
+		{"flag over file", cfg.Server.Port, "9000"},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+
+	if opts.Path != path || !reflect.DeepEqual(opts.Args, []string{"migrate"}) {
+		t.Errorf("options = %+v", opts)
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	path := writeFile(t, "config.yml", "server:\n  port: \"8000\"\n")
+	setenv(t, envPrefix+"CONFIG", path)
+
+	if cfg, _ := load(t); cfg.Server.Port != "8000" {
+		t.Errorf("port = %q, want the one from %s", cfg.Server.Port, path)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+	}{
+		{"missing file", "", nil, []string{"--config", "/nonexistent/config.yml"}},
+		{"unknown field", "database:\n  hots: db\n", nil, nil},
+		{"incorrect env", "", map[string]string{"TX_RETRIES": "many"}, nil},
+		{"incorrect flag", "", nil, []string{"--port-timeout", "1s"}},
+		{"unknown SSL mode", "", nil, []string{"--dbsslmode", "prefer"}},
+		{"certificate without key", "", nil, []string{"--dbsslcert", "client.pem"}},
+		{"missing password file", "", nil, []string{"--dbpassword-file", "/nonexistent/password"}},
+		{"unknown log level", "log:\n  level: trace\n", nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := test.args
+
+			if test.file != "" {
+				args = append([]string{"--config", writeFile(t, "config.yml", test.file)}, args...)
+			}
+
+			for key, value := range test.env {
+				setenv(t, envPrefix+key, value)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			if _, _, err := Load(flags, args, "/nonexistent/config.yml"); err == nil {
+				t.Error("error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestLoadPasswordFile(t *testing.T) {
+	path := writeFile(t, "db_password", "s3cret\r\n")
+	setenv(t, envPrefix+"DB_PASSWORD", "from-env")
+	setenv(t, envPrefix+"DB_PASSWORD_FILE", path)
+
+	// The file takes precedence over the password itself, so the secret isn't left behind.
+	if cfg, _ := load(t); cfg.Database.Password != "s3cret" {
+		t.Errorf("password = %q, want the one from the file without the line break", cfg.Database.Password)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		secrets []string
+		want    string
+	}{
+		{"password", []string{"--dbpassword", "s3cret"}, []string{"s3cret"}, "password: " + redacted},
+		{"URL DSN", []string{"--dsn", "postgres://user:s3cret@db/app"}, []string{"s3cret"},
+			"dsn: postgres://user:xxxxx@db/app"},
+		{"key=value DSN", []string{"--dsn", "host=db password=s3cret"}, []string{"s3cret"},
+			"dsn: " + redacted},
+		{"DSN without password", []string{"--dsn", "host=db dbname=app"}, nil, "dsn: host=db dbname=app"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, opts := load(t, append([]string{"--print-config"}, test.args...)...)
+
+			if !opts.Print {
+				t.Error("Print = false, want true")
+			}
+
+			var buf bytes.Buffer
+
+			if err := cfg.Print(&buf); err != nil {
+				t.Fatal(err)
+			}
+
+			printed := buf.String()
+
+			for _, secret := range test.secrets {
+				if strings.Contains(printed, secret) {
+					t.Errorf("the secret %q is printed:\n%s", secret, printed)
+				}
+			}
+
+			if !strings.Contains(printed, test.want) {
+				t.Errorf("%q isn't printed:\n%s", test.want, printed)
+			}
+		})
+	}
+}
+
+func TestConnectionString(t *testing.T) {
+	tests := []struct {
+		name string
+		db   DatabaseConfig
+		want string
+	}{
+		{"settings",
+			DatabaseConfig{Host: "db:5432", Name: "app", Username: "user", Password: "p@ss"},
+			"postgres://user:p%40ss@db:5432/app?sslmode=disable"},
+		{"settings with certificates",
+			DatabaseConfig{Host: "db", Name: "app", Username: "user", SSLMode: "verify-full",
+				SSLRootCert: "ca.pem", SSLCert: "client.pem", SSLKey: "client.key"},
+			"postgres://user:@db/app?sslcert=client.pem&sslkey=client.key&sslmode=verify-full&sslrootcert=ca.pem"},
+		{"URL DSN",
+			DatabaseConfig{DSN: "postgres://user@db/app", Host: "other", Password: "s3cret", SSLMode: "require"},
+			"postgres://user:s3cret@db/app?sslmode=require"},
+		{"URL DSN with its own settings",
+			DatabaseConfig{DSN: "postgres://user:own@db/app?sslmode=verify-ca", Password: "s3cret",
+				SSLMode: "require"},
+			"postgres://user:own@db/app?sslmode=verify-ca"},
+		{"URL DSN without settings",
+			DatabaseConfig{DSN: "postgres://user@db/app"},
+			"postgres://user@db/app"},
+		{"key=value DSN",
+			DatabaseConfig{DSN: "host=db user=x dbname=y", Password: `it's\`, SSLMode: "verify-full",
+				SSLRootCert: "ca.pem"},
+			`host=db user=x dbname=y password='it\'s\\' sslmode='verify-full' sslrootcert='ca.pem'`},
+		{"key=value DSN with its own settings",
+			DatabaseConfig{DSN: `host=db password = 'a b\' c' sslmode=disable`, Password: "s3cret",
+				SSLMode: "require"},
+			`host=db password = 'a b\' c' sslmode=disable`},
+		{"key=value DSN without settings",
+			DatabaseConfig{DSN: "host=db user=x"},
+			"host=db user=x"},
+	}
+
+	for _, test := range tests {
+		if got := test.db.ConnectionString(); got != test.want {
+			t.Errorf("%s: ConnectionString() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
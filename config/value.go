@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// value makes a field of the configuration settable from a string
+// so it can be bound to a flag and an environment variable.
+type value struct {
+	ptr interface{}
+}
+
+func (v value) String() string {
+	switch ptr := v.ptr.(type) {
+	case *string:
+		return *ptr
+
+	case *int:
+		return strconv.Itoa(*ptr)
+
+	case *bool:
+		return strconv.FormatBool(*ptr)
+
+	case *time.Duration:
+		return ptr.String()
+	}
+
+	return ""
+}
+
+func (v value) Set(text string) error {
+	switch ptr := v.ptr.(type) {
+	case *string:
+		*ptr = text
+
+	case *int:
+		number, err := strconv.Atoi(text)
+
+		if err != nil {
+			return err
+		}
+
+		*ptr = number
+
+	case *bool:
+		flag, err := strconv.ParseBool(text)
+
+		if err != nil {
+			return err
+		}
+
+		*ptr = flag
+
+	case *time.Duration:
+		duration, err := time.ParseDuration(text)
+
+		if err != nil {
+			return err
+		}
+
+		*ptr = duration
+
+	default:
+		return fmt.Errorf("unsupported setting type %T", v.ptr)
+	}
+
+	return nil
+}
+
+// IsBoolFlag allows the boolean flags to be specified without a value.
+func (v value) IsBoolFlag() bool {
+	_, ok := v.ptr.(*bool)
+	return ok
+}
@@ -1,8 +1,9 @@
 module restApp
 
-go 1.15
+go 1.16
 
 require (
 	github.com/gorilla/mux v1.8.0
 	github.com/lib/pq v1.8.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,269 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the format of the time in the names of the rotated files.
+const backupTimeFormat = "20060102T150405.000"
+
+// compressedSuffix is the suffix of the names of the compressed rotated files.
+const compressedSuffix = ".gz"
+
+// RotationPolicy specifies when the log file is rotated and how long the rotated files are kept.
+type RotationPolicy struct {
+	// MaxSize is the size in bytes the file is rotated after, 0 means no limit.
+	MaxSize int64
+	// Interval is the time the file is rotated after, 0 means no limit.
+	Interval time.Duration
+	// MaxBackups is the number of the rotated files kept, 0 means all of them.
+	MaxBackups int
+	// Compress means the rotated files are compressed with gzip.
+	Compress bool
+}
+
+// File is the log file rotated according to the policy. The rotated files are
+// named after the file with the time of the rotation appended, e.g. sys.log.20201231T235959.000.
+type File struct {
+	path     string
+	policy   RotationPolicy
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	// cleanup serializes the compression and the removal of the rotated files.
+	cleanup sync.Mutex
+}
+
+// Write writes the data to the file rotating it first if necessary.
+func (f *File) Write(data []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.rotationDue(int64(len(data))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *File) rotationDue(size int64) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.policy.MaxSize > 0 && f.size+size > f.policy.MaxSize {
+		return true
+	}
+
+	return f.policy.Interval > 0 && time.Since(f.openedAt) >= f.policy.Interval
+}
+
+// rotate renames the current file and opens a new one.
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	f.file = nil
+	backup := f.path + "." + time.Now().Format(backupTimeFormat)
+
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("couldn't rotate the log file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	go f.cleanupBackups(backup)
+
+	return nil
+}
+
+// Reopen closes and opens the file again. It's meant for the external rotation
+// that renames the file and expects the application to create a new one.
+func (f *File) Reopen() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+
+		f.file = nil
+	}
+
+	return f.open()
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		file.Close()
+
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+
+	return nil
+}
+
+// cleanupBackups compresses the rotated file if required
+// and removes the rotated files exceeding the number of the kept ones.
+// The errors are written to stderr because the log itself may be broken.
+func (f *File) cleanupBackups(backup string) {
+	f.cleanup.Lock()
+	defer f.cleanup.Unlock()
+
+	if f.policy.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintln(os.Stderr, "Couldn't compress the rotated log file:", err)
+		}
+	}
+
+	if f.policy.MaxBackups <= 0 {
+		return
+	}
+
+	backups, err := f.backups()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Couldn't list the rotated log files:", err)
+
+		return
+	}
+
+	for len(backups) > f.policy.MaxBackups {
+		if err = os.Remove(backups[0]); err != nil {
+			fmt.Fprintln(os.Stderr, "Couldn't remove the rotated log file:", err)
+		}
+
+		backups = backups[1:]
+	}
+}
+
+// backups returns the rotated files from the oldest to the newest.
+func (f *File) backups() ([]string, error) {
+	dir := filepath.Dir(f.path)
+	prefix := filepath.Base(f.path) + "."
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressedSuffix)
+
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+
+		backups = append(backups, filepath.Join(dir, name))
+	}
+
+	// The names sort in the order of the rotation because of the time format.
+	sort.Strings(backups)
+
+	return backups, nil
+}
+
+// compressFile replaces the file with its gzip-compressed copy.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressedSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(path + compressedSuffix)
+
+		return err
+	}
+
+	src.Close()
+
+	return os.Remove(path)
+}
+
+// OpenFile opens the log file for appending, creating it if it doesn't exist.
+func OpenFile(path string, policy RotationPolicy) (*File, error) {
+	f := &File{path: path, policy: policy}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,192 @@
+// Package logging writes the log of the application as JSON lines
+// with levels and key-value fields.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of the log entry.
+type Level int8
+
+// Log levels.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (level Level) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("level(%d)", level)
+}
+
+// ParseLevel returns the level with the specified name.
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown log level: %s", name)
+}
+
+// output is the destination shared by the logger and all the loggers derived from it.
+type output struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// Logger writes the entries of the specified level and higher.
+// Every entry is a JSON object on a separate line with the time, the level,
+// the message and the fields of the logger and the entry.
+type Logger struct {
+	out    *output
+	level  Level
+	fields []interface{}
+}
+
+// Enabled checks if the entries of the level are written.
+func (logger *Logger) Enabled(level Level) bool {
+	return level >= logger.level
+}
+
+// With returns the logger adding the fields to all its entries.
+// The fields are specified as the alternating keys and values.
+func (logger *Logger) With(keyValues ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(logger.fields)+len(keyValues))
+	fields = append(fields, logger.fields...)
+	fields = append(fields, keyValues...)
+
+	return &Logger{logger.out, logger.level, fields}
+}
+
+// Debug writes the entry meant for debugging.
+func (logger *Logger) Debug(msg string, keyValues ...interface{}) {
+	logger.Log(LevelDebug, msg, keyValues...)
+}
+
+// Info writes the entry about the normal operation.
+func (logger *Logger) Info(msg string, keyValues ...interface{}) {
+	logger.Log(LevelInfo, msg, keyValues...)
+}
+
+// Warn writes the entry about the problem the application has recovered from.
+func (logger *Logger) Warn(msg string, keyValues ...interface{}) {
+	logger.Log(LevelWarn, msg, keyValues...)
+}
+
+// Error writes the entry about the failed operation.
+func (logger *Logger) Error(msg string, keyValues ...interface{}) {
+	logger.Log(LevelError, msg, keyValues...)
+}
+
+// Fatal writes the error entry and exits the application.
+func (logger *Logger) Fatal(msg string, keyValues ...interface{}) {
+	logger.Log(LevelError, msg, keyValues...)
+	os.Exit(1)
+}
+
+// Log writes the entry of the specified level.
+func (logger *Logger) Log(level Level, msg string, keyValues ...interface{}) {
+	if !logger.Enabled(level) {
+		return
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"time":`)
+	writeValue(&buf, time.Now().UTC().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeValue(&buf, level.String())
+	buf.WriteString(`,"msg":`)
+	writeValue(&buf, RedactText(msg))
+	writeFields(&buf, logger.fields)
+	writeFields(&buf, keyValues)
+	buf.WriteString("}\n")
+
+	logger.out.mutex.Lock()
+	defer logger.out.mutex.Unlock()
+
+	logger.out.w.Write(buf.Bytes())
+}
+
+// StdLogger returns the standard logger writing the entries of the specified level.
+// It's meant for the libraries that accept only *log.Logger.
+func (logger *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(&stdWriter{logger, level}, "", 0)
+}
+
+// stdWriter writes every line it receives as a separate entry.
+type stdWriter struct {
+	logger *Logger
+	level  Level
+}
+
+func (w *stdWriter) Write(data []byte) (int, error) {
+	w.logger.Log(w.level, strings.TrimRight(string(data), "\n"))
+
+	return len(data), nil
+}
+
+func writeFields(buf *bytes.Buffer, keyValues []interface{}) {
+	for i := 0; i < len(keyValues); i += 2 {
+		key := fmt.Sprint(keyValues[i])
+		var value interface{} = "<missing>"
+
+		if i+1 < len(keyValues) {
+			value = keyValues[i+1]
+		}
+
+		buf.WriteByte(',')
+		writeValue(buf, key)
+		buf.WriteByte(':')
+		writeValue(buf, redactField(key, value))
+	}
+}
+
+func writeValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+
+	case time.Duration:
+		value = v.String()
+
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	buf.Write(data)
+}
+
+// New returns the logger writing the entries of the specified level and higher to w.
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{out: &output{w: w}, level: level}
+}
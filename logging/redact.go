@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces the personal data and the secrets in the log.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are the names of the fields never written to the log as is.
+// They match both the fields of the entries and the fields of the logged JSON documents.
+var sensitiveKeys = map[string]bool{
+	"email":         true,
+	"phone":         true,
+	"phone_number":  true,
+	"tax_id":        true,
+	"password":      true,
+	"authorization": true,
+	"token":         true,
+	"api_key":       true,
+}
+
+// emailPattern matches the email addresses in the free text such as the messages of the errors.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+func isSensitive(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// RedactText masks the email addresses in the text.
+func RedactText(text string) string {
+	return emailPattern.ReplaceAllString(text, redacted)
+}
+
+// RedactJSON masks the values of the sensitive fields of the JSON document
+// at any depth. The data that isn't JSON is redacted as the text.
+func RedactJSON(data []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+
+	if err := decoder.Decode(&document); err != nil {
+		return []byte(RedactText(string(data)))
+	}
+
+	redacted, err := json.Marshal(redactDocument(document))
+
+	if err != nil {
+		return []byte(RedactText(string(data)))
+	}
+
+	return redacted
+}
+
+func redactDocument(document interface{}) interface{} {
+	switch v := document.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactDocument(value)
+			}
+		}
+
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactDocument(value)
+		}
+
+	case string:
+		return RedactText(v)
+	}
+
+	return document
+}
+
+// redactField returns the value of the entry field safe to be written to the log.
+func redactField(key string, value interface{}) interface{} {
+	if isSensitive(key) {
+		return redacted
+	}
+
+	switch v := value.(type) {
+	case string:
+		return RedactText(v)
+
+	case error:
+		return RedactText(v.Error())
+	}
+
+	return value
+}
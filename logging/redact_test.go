@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"top level",
+			`{"name":"Romashka","email":"info@romashka.example","phone_number":"+79161234567","tax_id":"7707083893"}`,
+			`{"name":"Romashka","email":"[REDACTED]","phone_number":"[REDACTED]","tax_id":"[REDACTED]"}`},
+		{"nested",
+			`{"data":[{"id":1,"customer":{"Email":"a@b.example","TAX_ID":"7707083893"}}],"total":1}`,
+			`{"data":[{"id":1,"customer":{"Email":"[REDACTED]","TAX_ID":"[REDACTED]"}}],"total":1}`},
+		{"sensitive object", `{"phone_number":{"country":"7","number":"9161234567"}}`,
+			`{"phone_number":"[REDACTED]"}`},
+		{"email in a message",
+			`{"error":{"message":"the customer with email a.b@c.example already exists"}}`,
+			`{"error":{"message":"the customer with email [REDACTED] already exists"}}`},
+		{"array", `["a@b.example","c"]`, `["[REDACTED]","c"]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := RedactJSON([]byte(test.data))
+
+			var gotValue, wantValue interface{}
+
+			if err := json.Unmarshal(got, &gotValue); err != nil {
+				t.Fatalf("the result isn't JSON: %s", got)
+			}
+
+			if err := json.Unmarshal([]byte(test.want), &wantValue); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("RedactJSON(%s) = %s, want %s", test.data, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedactJSONNumbers(t *testing.T) {
+	// The numbers aren't rounded through float64.
+	data := `{"price":1.10,"quantity":12345678901234567890}`
+
+	if got := string(RedactJSON([]byte(data))); got != data {
+		t.Errorf("RedactJSON(%s) = %s", data, got)
+	}
+}
+
+func TestRedactJSONText(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"contact a@b.example for details", "contact [REDACTED] for details"},
+		{`{"email":"a@b.example"`, `{"email":"[REDACTED]"`},
+		{"name=x&email=a%40b.example", "name=x&email=a%40b.example"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := string(RedactJSON([]byte(test.data))); got != test.want {
+			t.Errorf("RedactJSON(%q) = %q, want %q", test.data, got, test.want)
+		}
+
+		if got := RedactText(test.data); got != test.want {
+			t.Errorf("RedactText(%q) = %q, want %q", test.data, got, test.want)
+		}
+	}
+}
+
+func TestRedactField(t *testing.T) {
+	tests := []struct {
+		key   string
+		value interface{}
+		want  interface{}
+	}{
+		{"email", "a@b.example", redacted},
+		{"Authorization", "Bearer abc", redacted},
+		{"api_key", 42, redacted},
+		{"error", errors.New(`pq: duplicate key value email "a@b.example"`), `pq: duplicate key value email "[REDACTED]"`},
+		{"message", "sent to a@b.example", "sent to [REDACTED]"},
+		{"status", 200, 200},
+		{"path", "/customers/1", "/customers/1"},
+	}
+
+	for _, test := range tests {
+		if got := redactField(test.key, test.value); got != test.want {
+			t.Errorf("redactField(%q, %v) = %v, want %v", test.key, test.value, got, test.want)
+		}
+	}
+}
+
+func TestLoggerRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, LevelDebug)
+	logger.Warn("Couldn't add the customer", "email", "a@b.example",
+		"error", errors.New("a@b.example is taken"))
+
+	if strings.Contains(buf.String(), "a@b.example") {
+		t.Errorf("the email is written to the log: %s", buf.String())
+	}
+}
@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"restApp/auth"
+	"restApp/config"
+	"restApp/logging"
+	"restApp/metrics"
+	"restApp/migrate"
 	"restApp/repo"
 	"restApp/rest"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -19,39 +33,36 @@ import (
 
 // Configuration constants for the application.
 const (
-	LOG      = "/var/log/rest/sys.log"
-	CONFIG   = "config.yml"
-	PREFIX   = "app: "
-	DRIVER   = "postgres"
-	PROTOCOL = "postgres"
+	CONFIG = "config.yml"
+	DRIVER = "postgres"
 )
 
-var (
-	dbUsername string
-	dbPassword string
-	dbHost     string
-	dbName     string
+// healthCheckTimeout limits the time of the readiness checks.
+const healthCheckTimeout = 2 * time.Second
 
-	address string
-	port    string
-)
-
-// parseFlags parses command line arguments and assigns them to global variables.
-func parseFlags() {
-	flag.StringVar(&dbUsername, "dbusername", "", "A username to access the database")
-	flag.StringVar(&dbPassword, "dbpassword", "", "A password to access the database")
-	flag.StringVar(&dbHost, "dbhost", "", "A host on which the DBMS is deployed")
-	flag.StringVar(&dbName, "dbname", "", "A name of the database")
-
-	flag.StringVar(&address, "address", "", "An address to listen on")
-	flag.StringVar(&port, "port", "80", "A port to listen on")
+// usage prints the help message of the application.
+func usage() {
+	out := flag.CommandLine.Output()
 
-	flag.Parse()
+	fmt.Fprintf(out, "Usage: %s [flags] [command]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  migrate up        apply all the pending migrations")
+	fmt.Fprintln(out, "  migrate down      revert the last applied migration")
+	fmt.Fprintln(out, "  migrate to N      migrate the database schema to version N")
+	fmt.Fprintln(out, "  migrate status    print the status of the migrations")
+	fmt.Fprintln(out, "  apikey create NAME ROLE[,ROLE...] [CUSTOMER_ID]")
+	fmt.Fprintln(out, "                    create a new API key with the roles admin, sales or customer")
+	fmt.Fprintln(out, "                    optionally limited to the customer and print it,")
+	fmt.Fprintln(out, "                    the customer role requires CUSTOMER_ID")
+	fmt.Fprintln(out, "  apikey list       print all the API keys")
+	fmt.Fprintln(out, "  apikey revoke ID  revoke the API key")
+	fmt.Fprintln(out, "  healthcheck [live|ready]")
+	fmt.Fprintln(out, "                    check if the running server is alive or ready (default)")
+	fmt.Fprintln(out, "\nWithout a command the REST API server is started.\n\nFlags:")
+	flag.PrintDefaults()
 }
 
 func main() {
-	parseFlags()
-
 	// Change working directory to the application directory.
 	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 
@@ -59,52 +70,579 @@ func main() {
 		log.Fatalln("Couldn't get current application folder path:", err)
 	}
 
+	// Load the configuration before changing the directory
+	// so the paths on the command line are relative to the current one.
+	flag.Usage = usage
+	cfg, opts, err := config.Load(flag.CommandLine, os.Args[1:], filepath.Join(dir, CONFIG))
+
+	if err != nil {
+		log.Fatalln("Couldn't load configuration:", err)
+	}
+
+	if opts.Print {
+		if err = cfg.Print(os.Stdout); err != nil {
+			log.Fatalln("Couldn't print configuration:", err)
+		}
+
+		return
+	}
+
+	// The health check queries the running server
+	// and must not touch its log and database.
+	if len(opts.Args) > 0 && opts.Args[0] == "healthcheck" {
+		if err = healthcheck(cfg, opts.Args[1:]); err != nil {
+			log.Fatalln("Health check failed:", err)
+		}
+
+		return
+	}
+
 	err = os.Chdir(dir)
 
 	if err != nil {
 		log.Fatalln("Couldn't change directory to bin:", err)
 	}
 
-	// Create a log file and a logger.
-	file, err := os.OpenFile(LOG, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0666)
+	// Create a logger writing to stdout and to the log file if specified.
+	var stream io.Writer = os.Stdout
+	closeLog := func() {}
+
+	if cfg.Log.Path != "" {
+		file, err := logging.OpenFile(cfg.Log.Path, logging.RotationPolicy{
+			MaxSize:    int64(cfg.Log.MaxSize) << 20,
+			Interval:   cfg.Log.RotateInterval,
+			MaxBackups: cfg.Log.MaxBackups,
+			Compress:   cfg.Log.Compress,
+		})
+
+		if err != nil {
+			log.Fatalln("Couldn't open log file:", err)
+		}
+
+		stream = io.MultiWriter(os.Stdout, file)
+		closeLog = func() { file.Close() }
+		reopenOnHangup(file)
+	}
+	defer closeLog()
+
+	level, err := logging.ParseLevel(cfg.Log.Level)
 
 	if err != nil {
-		log.Fatalln("Couldn't open log file:", err)
+		log.Fatalln("Couldn't set up the log:", err)
 	}
-	defer file.Close()
 
-	stream := io.MultiWriter(os.Stdout, file)
-	logger := log.New(stream, PREFIX, log.LstdFlags|log.Lshortfile)
+	logger := logging.New(stream, level)
 
 	// Open database connection.
-	db, err := sql.Open(DRIVER, fmt.Sprintf("%s://%s:%s@%s/%s?sslmode=disable",
-		PROTOCOL, dbUsername, dbPassword, dbHost, dbName))
+	db, err := sql.Open(DRIVER, cfg.Database.ConnectionString())
+
+	if err != nil {
+		logger.Fatal("Couldn't establish a db connection", "error", err)
+	}
+
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+	// sql.Open doesn't connect, so the database is pinged
+	// to make sure it's available before serving the requests.
+	err = waitForDB(db, cfg.Database.ConnectTimeout, logger)
 
 	if err != nil {
-		logger.Fatalln("Couldn't establish a db connection:", err)
+		logger.Fatal("Couldn't connect to the database", "error", err)
+	}
+
+	// Run the command instead of the server if specified.
+	if len(opts.Args) > 0 {
+		err = runCommand(opts.Args, db, logger)
+		db.Close()
+
+		if err != nil {
+			logger.Error("Command failed", "error", err)
+			closeLog()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if cfg.Database.Migrate {
+		err = migrateDB(context.Background(), db, logger, []string{"up"})
+
+		if err != nil {
+			logger.Fatal("Couldn't migrate the database", "error", err)
+		}
 	}
-	defer db.Close()
 
 	// Create data repositories.
-	customerRepo := repo.NewCustomerRepo(db)
-	serviceRepo := repo.NewServiceRepo(db)
-	orderRepo := repo.NewOrderRepository(db)
+	isolation, err := repo.ParseIsolationLevel(cfg.Database.TxIsolation)
+
+	if err != nil {
+		logger.Fatal("Couldn't set up transactions", "error", err)
+	}
+
+	stmts, err := repo.NewStatements(db)
+
+	if err != nil {
+		logger.Fatal("Couldn't load SQL scripts", "error", err)
+	}
+
+	// Collect the metrics of the database and the requests.
+	registry := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+	stmts.SetObserver(metrics.NewDBMetrics(registry))
+	metrics.RegisterDBStats(registry, db)
+
+	customerRepo := repo.NewCustomerRepo(db, stmts)
+	serviceRepo := repo.NewServiceRepo(db, stmts)
+	orderRepo := repo.NewOrderRepository(db, stmts)
+	taxRateRepo := repo.NewTaxRateRepo(db, stmts)
+	exchangeRateRepo := repo.NewExchangeRateRepo(db, stmts)
+	invoiceRepo := repo.NewInvoiceRepo(db, stmts)
+	uow := repo.NewUnitOfWork(db, stmts, isolation, cfg.Database.TxRetries)
+
+	migrator, err := migrate.NewMigrator(db, logger)
+
+	if err != nil {
+		logger.Fatal("Couldn't load migrations", "error", err)
+	}
 
 	// Create REST API controllers.
 	customerController := rest.NewCustomerController(customerRepo, logger)
 	serviceController := rest.NewServiceController(serviceRepo, logger)
-	orderController := rest.NewOrderController(orderRepo, serviceRepo, logger)
+	orderController := rest.NewOrderController(orderRepo, uow, logger)
+	taxRateController := rest.NewTaxRateController(taxRateRepo, logger)
+	exchangeRateController := rest.NewExchangeRateController(exchangeRateRepo, logger)
+	invoiceController := rest.NewInvoiceController(invoiceRepo, repo.Party{
+		Name:    cfg.Invoice.Issuer,
+		Address: cfg.Invoice.IssuerAddress,
+		TaxID:   cfg.Invoice.IssuerTaxID,
+	}, cfg.Invoice.PaymentDays, logger)
+	systemController := rest.NewSystemController(db, logger)
+	healthController := rest.NewHealthController(healthCheckTimeout, logger)
+
+	healthController.AddCheck("database", db.PingContext)
+	healthController.AddCheck("migrations", migrator.Check)
+	healthController.AddCheck("statements", stmts.PrepareAll)
 
-	// Setup REST routes.
+	// Setup REST routes. The API requires authentication
+	// while the health checks and the metrics stay open.
 	router := mux.NewRouter()
-	customers := router.PathPrefix("/customers").Subrouter()
-	services := router.PathPrefix("/services").Subrouter()
-	orders := router.PathPrefix("/orders").Subrouter()
+	api := router.NewRoute().Subrouter()
+	customers := api.PathPrefix("/customers").Subrouter()
+	services := api.PathPrefix("/services").Subrouter()
+	orders := api.PathPrefix("/orders").Subrouter()
+	taxRates := api.PathPrefix("/tax_rates").Subrouter()
+	exchangeRates := api.PathPrefix("/exchange_rates").Subrouter()
+	invoices := api.PathPrefix("/invoices").Subrouter()
+	system := api.PathPrefix("/system").Subrouter()
+	rest.SetupErrorHandlers(router, logger)
+	router.Use(rest.TimeoutMiddleware(cfg.Database.QueryTimeout))
+
+	if cfg.Auth.Enabled {
+		authenticator, err := newAuthenticator(&cfg.Auth, repo.NewAPIKeyRepo(db, stmts))
+
+		if err != nil {
+			logger.Fatal("Couldn't set up authentication", "error", err)
+		}
+
+		api.Use(rest.AuthMiddleware(authenticator, logger))
+	} else {
+		logger.Warn("Authentication is disabled, the API is open to anyone")
+	}
 
 	customerController.SetupRoutes(customers)
 	serviceController.SetupRoutes(services)
 	orderController.SetupRoutes(orders)
+	invoiceController.SetupOrderRoutes(orders)
+	taxRateController.SetupRoutes(taxRates)
+	exchangeRateController.SetupRoutes(exchangeRates)
+	invoiceController.SetupRoutes(invoices)
+	systemController.SetupRoutes(system)
+	healthController.SetupRoutes(router.NewRoute().Subrouter())
+	router.Handle("/metrics", registry).Methods("GET")
+
+	handler := httpMetrics.Middleware(router)(router)
+
+	if logger.Enabled(logging.LevelDebug) {
+		handler = rest.BodyLogMiddleware(logger)(handler)
+	}
+
+	handler = rest.RequestIDMiddleware(rest.AccessLogMiddleware(logger)(handler))
+
+	// Start the server and wait for it to stop.
+	server := &http.Server{
+		Addr:              fmt.Sprintf("%s:%s", cfg.Server.Address, cfg.Server.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		ErrorLog:          logger.StdLogger(logging.LevelWarn),
+	}
+	err = serve(server, cfg.Server.ShutdownTimeout, logger)
+
+	// Deferred functions aren't run by os.Exit,
+	// so the database is closed explicitly.
+	if closeErr := stmts.Close(); closeErr != nil {
+		logger.Error("Couldn't close the prepared statements", "error", closeErr)
+	}
+
+	if closeErr := db.Close(); closeErr != nil {
+		logger.Error("Couldn't close the db connection", "error", closeErr)
+	}
+
+	if err != nil {
+		logger.Error("Server stopped", "error", err)
+		closeLog()
+		os.Exit(1)
+	}
+
+	logger.Info("Server stopped gracefully")
+}
+
+// reopenOnHangup reopens the log file every time the process receives SIGHUP
+// so the file can be rotated by logrotate.
+func reopenOnHangup(file *logging.File) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	go func() {
+		for range hangups {
+			if err := file.Reopen(); err != nil {
+				fmt.Fprintln(os.Stderr, "Couldn't reopen log file:", err)
+			}
+		}
+	}()
+}
+
+// waitForDB pings the database until it responds or the timeout expires.
+// The delay between the attempts grows exponentially.
+func waitForDB(db *sql.DB, timeout time.Duration, logger *logging.Logger) error {
+	const (
+		pingTimeout = 5 * time.Second
+		minDelay    = 500 * time.Millisecond
+		maxDelay    = 10 * time.Second
+	)
+
+	deadline := time.Now().Add(timeout)
+	delay := minDelay
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err := db.PingContext(ctx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warn("Database is unavailable, retrying", "delay", delay, "error", err)
+		time.Sleep(delay)
+
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// serve runs the server until it fails or the process receives SIGINT or SIGTERM.
+// On a signal the server stops accepting new connections and waits
+// for the requests in progress to finish until the shutdown timeout expires.
+func serve(server *http.Server, shutdownTimeout time.Duration, logger *logging.Logger) error {
+	serverErrors := make(chan error, 1)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go func() {
+		logger.Info("Listening", "address", server.Addr)
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErrors:
+		return err
+
+	case sig := <-signals:
+		logger.Info("Shutting down", "signal", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+
+	if err != nil {
+		// Drop the connections still in progress.
+		server.Close()
+
+		return fmt.Errorf("couldn't finish the requests in progress: %w", err)
+	}
+
+	return nil
+}
+
+// healthcheck queries the health endpoint of the server running with the same configuration.
+// It returns an error if the server isn't alive or ready.
+func healthcheck(cfg *config.Config, args []string) error {
+	endpoint := "/readyz"
+
+	switch {
+	case len(args) == 0 || len(args) == 1 && args[0] == "ready":
+
+	case len(args) == 1 && args[0] == "live":
+		endpoint = "/healthz"
+
+	default:
+		return fmt.Errorf("incorrect healthcheck arguments: %s", strings.Join(args, " "))
+	}
+
+	host := cfg.Server.Address
+
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+
+	client := &http.Client{Timeout: healthCheckTimeout + time.Second}
+	response, err := client.Get("http://" + net.JoinHostPort(host, cfg.Server.Port) + endpoint)
+
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, 4096))
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", response.Status, body)
+	}
+
+	fmt.Println(string(body))
+
+	return nil
+}
+
+// newAuthenticator creates the authenticator accepting the API keys
+// and the tokens verified by the keys specified in the configuration.
+func newAuthenticator(cfg *config.AuthConfig, keys auth.APIKeyStore) (*auth.Authenticator, error) {
+	verifier := auth.NewVerifier(cfg.JWTIssuer, cfg.JWTAudience)
+
+	if cfg.JWTSecretFile != "" {
+		if err := verifier.AddHMACKey(cfg.JWTSecretFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		if err := verifier.AddRSAKey(cfg.JWTPublicKeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.JWKSFile != "" {
+		if err := verifier.AddJWKS(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if !verifier.HasKeys() {
+		verifier = nil
+	}
+
+	return auth.NewAuthenticator(keys, verifier), nil
+}
+
+// runCommand runs the command specified on the command line.
+func runCommand(args []string, db *sql.DB, logger *logging.Logger) error {
+	switch args[0] {
+	case "migrate":
+		return migrateDB(context.Background(), db, logger, args[1:])
+
+	case "apikey":
+		return manageAPIKeys(context.Background(), db, args[1:])
+	}
+
+	flag.Usage()
+
+	return fmt.Errorf("unknown command: %s", args[0])
+}
+
+// migrateDB runs the migrate command with the specified arguments.
+func migrateDB(ctx context.Context, db *sql.DB, logger *logging.Logger, args []string) error {
+	migrator, err := migrate.NewMigrator(db, logger)
+
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("migrate requires one of up, down, to N and status")
+	}
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		return migrator.Up(ctx)
+
+	case args[0] == "down" && len(args) == 1:
+		return migrator.Down(ctx)
+
+	case args[0] == "to" && len(args) == 2:
+		version, err := strconv.ParseInt(args[1], 10, 64)
+
+		if err != nil || version < 0 {
+			return fmt.Errorf("incorrect migration version: %s", args[1])
+		}
+
+		return migrator.To(ctx, version)
+
+	case args[0] == "status" && len(args) == 1:
+		statuses, err := migrator.Status(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			applied := "pending"
+
+			if status.Applied {
+				applied = "applied at " + status.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%04d %-40s %s\n", status.Migration.Version,
+				status.Migration.Name, applied)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("incorrect migrate arguments: %s", strings.Join(args, " "))
+}
+
+// manageAPIKeys runs the apikey command with the specified arguments.
+func manageAPIKeys(ctx context.Context, db *sql.DB, args []string) error {
+	stmts, err := repo.NewStatements(db)
+
+	if err != nil {
+		return err
+	}
+	defer stmts.Close()
+
+	keys := repo.NewAPIKeyRepo(db, stmts)
+
+	switch {
+	case (len(args) == 3 || len(args) == 4) && args[0] == "create":
+		key, err := newAPIKey(args[1], args[2], args[3:])
+
+		if err != nil {
+			return err
+		}
+
+		secret, err := auth.GenerateAPIKey()
+
+		if err != nil {
+			return fmt.Errorf("couldn't generate API key: %w", err)
+		}
+
+		key.Hash = auth.HashAPIKey(secret)
+		err = keys.AddAPIKey(ctx, key)
+
+		if errors.Is(err, repo.ErrUniqueViolation) {
+			return fmt.Errorf("API key %s already exists", key.Name)
+		}
+
+		if errors.Is(err, repo.ErrForeignKeyViolation) {
+			return fmt.Errorf("customer %d doesn't exist", *key.CustomerID)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created API key %d %s, store it now as it can't be shown again:\n%s\n",
+			key.ID, key.Name, secret)
+
+		return nil
+
+	case len(args) == 1 && args[0] == "list":
+		list, err := keys.GetAllAPIKeys(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		for _, key := range list {
+			status := "active"
+
+			if key.RevokedAt != nil {
+				status = "revoked at " + key.RevokedAt.Format(time.RFC3339)
+			}
+
+			roles := strings.Join(key.Roles, ",")
+
+			if key.CustomerID != nil {
+				roles += fmt.Sprintf(" of customer %d", *key.CustomerID)
+			}
+
+			fmt.Printf("%4d %-40s %-30s created at %s, %s\n", key.ID, key.Name, roles,
+				key.CreatedAt.Format(time.RFC3339), status)
+		}
+
+		return nil
+
+	case len(args) == 2 && args[0] == "revoke":
+		id, err := strconv.ParseInt(args[1], 10, 64)
+
+		if err != nil {
+			return fmt.Errorf("incorrect API key ID: %s", args[1])
+		}
+
+		err = keys.RevokeAPIKey(ctx, id)
+
+		if errors.Is(err, repo.ErrNotFound) {
+			return fmt.Errorf("API key %d doesn't exist or is already revoked", id)
+		}
+
+		return err
+	}
+
+	return fmt.Errorf("incorrect apikey arguments: %s", strings.Join(args, " "))
+}
+
+// newAPIKey returns the API key with the name, the comma-separated roles
+// and the ID of the customer the key is limited to, which the customer role requires.
+func newAPIKey(name, roles string, customer []string) (*repo.APIKey, error) {
+	key := &repo.APIKey{Name: name, Roles: strings.Split(roles, ",")}
+	customerRole := false
+
+	for _, role := range key.Roles {
+		if !auth.IsRole(role) {
+			return nil, fmt.Errorf("unknown role: %s", role)
+		}
+
+		customerRole = customerRole || role == auth.RoleCustomer
+	}
+
+	if len(customer) > 0 {
+		id, err := strconv.ParseInt(customer[0], 10, 64)
+
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("incorrect customer ID: %s", customer[0])
+		}
+
+		key.CustomerID = &id
+	}
+
+	// The customers without the customer ID would see the data of every customer.
+	if customerRole && key.CustomerID == nil {
+		return nil, fmt.Errorf("the customer role requires the customer ID")
+	}
 
-	addr := fmt.Sprintf("%s:%s", address, port)
-	http.ListenAndServe(addr, router)
+	return key, nil
 }
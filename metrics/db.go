@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// DBMetrics contains the metrics of the queries the repositories execute.
+// It implements repo.QueryObserver.
+type DBMetrics struct {
+	duration *HistogramVec
+}
+
+// ObserveQuery records the duration of the query run from the script.
+func (m *DBMetrics) ObserveQuery(script string, duration time.Duration, err error) {
+	result := "ok"
+
+	if err != nil {
+		result = "error"
+	}
+
+	script = strings.TrimSuffix(strings.TrimPrefix(script, "sql/"), ".sql")
+	m.duration.Observe(duration.Seconds(), script, result)
+}
+
+// NewDBMetrics creates the metrics of the database queries and registers them.
+func NewDBMetrics(registry *Registry) *DBMetrics {
+	m := &DBMetrics{
+		duration: NewHistogramVec("db_query_duration_seconds",
+			"Duration of the database queries by SQL script.", DefaultBuckets, "script", "result"),
+	}
+
+	registry.Register(m.duration)
+
+	return m
+}
+
+// RegisterDBStats registers the statistics of the database connection pool.
+func RegisterDBStats(registry *Registry, db interface{ Stats() sql.DBStats }) {
+	gauges := []struct {
+		name    string
+		help    string
+		counter bool
+		value   func(stats sql.DBStats) float64
+	}{
+		{"db_max_open_connections", "Maximum number of open connections to the database.", false,
+			func(stats sql.DBStats) float64 { return float64(stats.MaxOpenConnections) }},
+		{"db_open_connections", "Number of established connections to the database.", false,
+			func(stats sql.DBStats) float64 { return float64(stats.OpenConnections) }},
+		{"db_connections_in_use", "Number of connections currently in use.", false,
+			func(stats sql.DBStats) float64 { return float64(stats.InUse) }},
+		{"db_connections_idle", "Number of idle connections.", false,
+			func(stats sql.DBStats) float64 { return float64(stats.Idle) }},
+		{"db_wait_count_total", "Number of connections waited for.", true,
+			func(stats sql.DBStats) float64 { return float64(stats.WaitCount) }},
+		{"db_wait_duration_seconds_total", "Time blocked waiting for new connections.", true,
+			func(stats sql.DBStats) float64 { return stats.WaitDuration.Seconds() }},
+		{"db_max_idle_closed_total", "Number of connections closed due to the idle limit.", true,
+			func(stats sql.DBStats) float64 { return float64(stats.MaxIdleClosed) }},
+		{"db_max_idle_time_closed_total", "Number of connections closed due to the idle time limit.", true,
+			func(stats sql.DBStats) float64 { return float64(stats.MaxIdleTimeClosed) }},
+		{"db_max_lifetime_closed_total", "Number of connections closed due to the lifetime limit.", true,
+			func(stats sql.DBStats) float64 { return float64(stats.MaxLifetimeClosed) }},
+	}
+
+	for _, gauge := range gauges {
+		value := gauge.value
+		collect := func() float64 { return value(db.Stats()) }
+
+		if gauge.counter {
+			registry.Register(NewCounterFunc(gauge.name, gauge.help, collect))
+		} else {
+			registry.Register(NewGaugeFunc(gauge.name, gauge.help, collect))
+		}
+	}
+}
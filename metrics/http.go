@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute is the route label of the requests not matching any route.
+const unmatchedRoute = "unmatched"
+
+// routeVariable matches the variables of the route templates with their patterns.
+var routeVariable = regexp.MustCompile(`\{(\w+):[^}]*\}`)
+
+// knownMethods limits the values of the method label.
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// HTTPMetrics contains the metrics of the HTTP requests.
+type HTTPMetrics struct {
+	requests *CounterVec
+	duration *HistogramVec
+}
+
+// statusRecorder is the response writer remembering the status code of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(statusCode int) {
+	recorder.status = statusCode
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Middleware records the number and the duration of the requests handled by the router
+// labelled by the route template rather than the path so the IDs don't multiply the series.
+// It must wrap the router itself to see the requests not matching any route.
+func (m *HTTPMetrics) Middleware(router *mux.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: wr, status: http.StatusOK}
+			next.ServeHTTP(recorder, req)
+
+			method := req.Method
+
+			if !knownMethods[method] {
+				method = "OTHER"
+			}
+
+			route := routeTemplate(router, req)
+			status := strconv.Itoa(recorder.status)
+
+			m.requests.Inc(method, route, status)
+			m.duration.Observe(time.Since(start).Seconds(), method, route, status)
+		})
+	}
+}
+
+// routeTemplate returns the template of the route matching the request
+// with the patterns of the variables removed, e.g. /orders/{orderId}/services.
+func routeTemplate(router *mux.Router, req *http.Request) string {
+	var match mux.RouteMatch
+
+	if !router.Match(req, &match) || match.Route == nil || match.MatchErr != nil {
+		return unmatchedRoute
+	}
+
+	template, err := match.Route.GetPathTemplate()
+
+	if err != nil {
+		return unmatchedRoute
+	}
+
+	return routeVariable.ReplaceAllString(template, "{$1}")
+}
+
+// NewHTTPMetrics creates the metrics of the HTTP requests and registers them.
+func NewHTTPMetrics(registry *Registry) *HTTPMetrics {
+	m := &HTTPMetrics{
+		requests: NewCounterVec("http_requests_total",
+			"Number of HTTP requests handled.", "method", "route", "status"),
+		duration: NewHistogramVec("http_request_duration_seconds",
+			"Duration of handling HTTP requests.", DefaultBuckets, "method", "route", "status"),
+	}
+
+	registry.Register(m.requests)
+	registry.Register(m.duration)
+
+	return m
+}
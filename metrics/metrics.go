@@ -0,0 +1,272 @@
+// Package metrics collects the metrics of the application
+// and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType is the media type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds of the latency histograms in seconds.
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// labelEscaper escapes the label values as the text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Collector writes its metrics in the Prometheus text format.
+type Collector interface {
+	Collect(w io.Writer)
+}
+
+// Registry is the set of collectors exposed together.
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []Collector
+}
+
+// Register adds the collector to the registry.
+func (registry *Registry) Register(collector Collector) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.collectors = append(registry.collectors, collector)
+}
+
+// ServeHTTP writes the metrics of all the collectors.
+func (registry *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	registry.mutex.Lock()
+	collectors := append([]Collector(nil), registry.collectors...)
+	registry.mutex.Unlock()
+
+	var buf bytes.Buffer
+
+	for _, collector := range collectors {
+		collector.Collect(&buf)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(buf.Bytes())
+}
+
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry {
+	return new(Registry)
+}
+
+// metric contains the description shared by all the kinds of the metrics.
+type metric struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (m *metric) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, kind)
+}
+
+// key joins the label values into the key of the series.
+func (m *metric) key(values []string) string {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d",
+			m.name, len(m.labels), len(values)))
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs formats the labels of the series with the additional pairs appended.
+func (m *metric) labelPairs(key string, extra ...string) string {
+	var values []string
+
+	if len(m.labels) > 0 {
+		values = strings.Split(key, "\xff")
+	}
+
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+
+	for i, value := range values {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, m.labels[i], labelEscaper.Replace(value)))
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], labelEscaper.Replace(extra[i+1])))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec is a set of counters partitioned by the label values.
+type CounterVec struct {
+	metric
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+// Add adds the delta to the counter with the specified label values.
+func (counter *CounterVec) Add(delta float64, labelValues ...string) {
+	key := counter.key(labelValues)
+
+	counter.mutex.Lock()
+	counter.values[key] += delta
+	counter.mutex.Unlock()
+}
+
+// Inc increments the counter with the specified label values.
+func (counter *CounterVec) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Collect writes the counters.
+func (counter *CounterVec) Collect(w io.Writer) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	counter.header(w, "counter")
+	keys := make([]string, 0, len(counter.values))
+
+	for key := range counter.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", counter.name, counter.labelPairs(key),
+			formatFloat(counter.values[key]))
+	}
+}
+
+// NewCounterVec creates a new set of counters with the specified labels.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		metric: metric{name, help, labels},
+		values: make(map[string]float64),
+	}
+}
+
+// histogram is a single series of the histogram.
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec is a set of histograms partitioned by the label values.
+type HistogramVec struct {
+	metric
+	buckets []float64
+	mutex   sync.Mutex
+	values  map[string]*histogram
+}
+
+// Observe adds the value to the histogram with the specified label values.
+func (vec *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := vec.key(labelValues)
+
+	vec.mutex.Lock()
+	defer vec.mutex.Unlock()
+
+	h, ok := vec.values[key]
+
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(vec.buckets))}
+		vec.values[key] = h
+	}
+
+	for i, bound := range vec.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += value
+}
+
+// Collect writes the histograms.
+func (vec *HistogramVec) Collect(w io.Writer) {
+	vec.mutex.Lock()
+	defer vec.mutex.Unlock()
+
+	vec.header(w, "histogram")
+	keys := make([]string, 0, len(vec.values))
+
+	for key := range vec.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		h := vec.values[key]
+
+		for i, bound := range vec.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", vec.name,
+				vec.labelPairs(key, "le", formatFloat(bound)), h.counts[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", vec.name, vec.labelPairs(key, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", vec.name, vec.labelPairs(key), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", vec.name, vec.labelPairs(key), h.count)
+	}
+}
+
+// NewHistogramVec creates a new set of histograms with the specified buckets and labels.
+// The buckets are the sorted upper bounds of the values.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		metric:  metric{name, help, labels},
+		buckets: buckets,
+		values:  make(map[string]*histogram),
+	}
+}
+
+// Gauge is a single value computed on every collection.
+type Gauge struct {
+	metric
+	kind  string
+	value func() float64
+}
+
+// Collect writes the current value of the gauge.
+func (gauge *Gauge) Collect(w io.Writer) {
+	gauge.header(w, gauge.kind)
+	fmt.Fprintf(w, "%s %s\n", gauge.name, formatFloat(gauge.value()))
+}
+
+// NewGaugeFunc creates a new gauge with the value returned by the function.
+func NewGaugeFunc(name, help string, value func() float64) *Gauge {
+	return &Gauge{metric{name, help, nil}, "gauge", value}
+}
+
+// NewCounterFunc creates a new counter with the value returned by the function.
+// It's meant for the counters maintained by some other code.
+func NewCounterFunc(name, help string, value func() float64) *Gauge {
+	return &Gauge{metric{name, help, nil}, "counter", value}
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
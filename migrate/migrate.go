@@ -0,0 +1,359 @@
+// Package migrate applies and reverts the versioned migrations of the database schema.
+//
+// The migrations are embedded into the binary as pairs of scripts named
+// sql/migrations/NNNN_name.up.sql and sql/migrations/NNNN_name.down.sql.
+// The versions of the applied migrations are stored in the schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"restApp/assets"
+	"restApp/logging"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationsDir is the directory of the assets containing the migration scripts.
+const migrationsDir = "sql/migrations"
+
+// lockID is the key of the advisory lock held while the migrations are applied
+// so the replicas of the application started at once don't race.
+const lockID = 7243513
+
+// Statements maintaining the table of the applied migrations.
+const (
+	createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR (256) NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+)`
+	selectApplied   = "SELECT version, applied_at FROM schema_migrations"
+	selectVersion   = "SELECT COALESCE(MAX(version), 0) FROM schema_migrations"
+	insertMigration = "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)"
+	deleteMigration = "DELETE FROM schema_migrations WHERE version = $1"
+	lock            = "SELECT pg_advisory_lock($1)"
+	unlock          = "SELECT pg_advisory_unlock($1)"
+)
+
+// scriptPattern matches the names of the migration scripts.
+var scriptPattern = regexp.MustCompile(`^([0-9]+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single change of the database schema.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether the migration is applied to the database.
+type Status struct {
+	Migration *Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies and reverts the migrations.
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+	logger     *logging.Logger
+}
+
+// Load reads the migrations embedded into the binary sorted by their versions.
+func Load() ([]*Migration, error) {
+	entries, err := fs.ReadDir(assets.FS, migrationsDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		match := scriptPattern.FindStringSubmatch(name)
+
+		if match == nil {
+			return nil, fmt.Errorf("incorrect migration script name: %s", name)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("incorrect migration version: %s", name)
+		}
+
+		script, err := assets.FS.ReadFile(path.Join(migrationsDir, name))
+
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s",
+				version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d must have both up and down scripts",
+				migration.Version)
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the version of the last known migration.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status returns the status of every known migration.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{migration, ok, appliedAt})
+	}
+
+	return statuses, nil
+}
+
+// Check returns an error if the database schema isn't at the version of the last known migration.
+// Unlike the other methods, it doesn't create the migrations table.
+func (m *Migrator) Check(ctx context.Context) error {
+	var version int64
+	err := m.db.QueryRowContext(ctx, selectVersion).Scan(&version)
+
+	if err != nil {
+		return err
+	}
+
+	if version != m.Latest() {
+		return fmt.Errorf("the database schema is at version %d, expected %d", version, m.Latest())
+	}
+
+	return nil
+}
+
+// Up applies all the pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return m.revert(ctx, conn, m.migrations[i])
+			}
+		}
+
+		m.logger.Info("No migrations to revert")
+
+		return nil
+	})
+}
+
+// To applies or reverts the migrations so that the migrations up to
+// the specified version inclusive are applied and the later ones aren't.
+// Zero version reverts all the migrations.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version: %d", version)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		changed := false
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+
+			if _, ok := applied[migration.Version]; ok && migration.Version > version {
+				if err := m.revert(ctx, conn, migration); err != nil {
+					return err
+				}
+
+				changed = true
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+				if err := m.apply(ctx, conn, migration); err != nil {
+					return err
+				}
+
+				changed = true
+			}
+		}
+
+		if !changed {
+			m.logger.Info("The database schema is up to date")
+		}
+
+		return nil
+	})
+}
+
+// locked runs the function on a single connection holding the advisory lock
+// and passes it the versions of the applied migrations.
+func (m *Migrator) locked(ctx context.Context,
+	fn func(conn *sql.Conn, applied map[int64]time.Time) error) error {
+	conn, err := m.db.Conn(ctx)
+
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, lock, lockID); err != nil {
+		return fmt.Errorf("couldn't acquire the migration lock: %w", err)
+	}
+	// The lock is released anyway when the connection is closed.
+	defer conn.ExecContext(context.Background(), unlock, lockID)
+
+	// The migrations are read only after the lock is acquired
+	// because they may have been applied by some other replica.
+	applied, err := m.applied(ctx, conn)
+
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+// applied returns the versions of the applied migrations and the time they were applied at.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return nil, fmt.Errorf("couldn't create the migrations table: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, selectApplied)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration) error {
+	m.logger.Info("Applying migration", "version", migration.Version, "name", migration.Name)
+
+	return m.run(ctx, conn, migration.Up, insertMigration, migration.Version, migration.Name)
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration *Migration) error {
+	m.logger.Info("Reverting migration", "version", migration.Version, "name", migration.Name)
+
+	return m.run(ctx, conn, migration.Down, deleteMigration, migration.Version)
+}
+
+// run executes the migration script and records the change
+// in the migrations table in a single transaction.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn,
+	script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+	// Rollback does nothing if the transaction is committed.
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %v failed: %w", args[0], err)
+	}
+
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+
+	return nil
+}
+
+// NewMigrator creates a new migrator for the migrations embedded into the binary.
+func NewMigrator(db *sql.DB, logger *logging.Logger) (*Migrator, error) {
+	migrations, err := Load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db, migrations, logger}, nil
+}
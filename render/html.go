@@ -0,0 +1,21 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"restApp/assets"
+	"restApp/repo"
+)
+
+// invoiceTemplate is the HTML template of the invoices.
+var invoiceTemplate = template.Must(template.New("invoice.html").
+	Funcs(template.FuncMap{"date": formatDate}).
+	ParseFS(assets.FS, "templates/invoice.html"))
+
+// InvoiceHTML writes the invoice as an HTML document.
+func InvoiceHTML(w io.Writer, invoice *repo.Invoice) error {
+	return invoiceTemplate.Execute(w, struct {
+		Title string
+		*repo.Invoice
+	}{title(invoice), invoice})
+}
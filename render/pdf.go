@@ -0,0 +1,541 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"restApp/repo"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The pages are A4 in points.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	marginLeft   = 50
+	marginRight  = pageWidth - 50
+	marginTop    = pageHeight - 50
+	marginBottom = 60
+)
+
+// Names of the fonts in the resources of the pages.
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// fonts are embedded into the documents, so any text they have the glyphs for,
+// such as Cyrillic, is shown as is.
+var fonts = map[string]*trueTypeFont{
+	fontRegular: mustLoadFont("fonts/DejaVuSans.ttf", "DejaVuSans"),
+	fontBold:    mustLoadFont("fonts/DejaVuSans-Bold.ttf", "DejaVuSans-Bold"),
+}
+
+// fontNames are the names of the fonts in the order of their objects.
+var fontNames = []string{fontRegular, fontBold}
+
+// ErrUnsupportedText is returned when the invoice contains characters the fonts have no glyphs for.
+// Such invoices are rendered to HTML only, so the documents never show placeholders instead of the text.
+var ErrUnsupportedText = errors.New("the fonts of the PDF documents can't show the text")
+
+// Positions of the columns of the lines table. The numbers are aligned to the right edges.
+const (
+	columnPosition  = marginLeft
+	columnTitle     = 72
+	titleWidth      = 170
+	columnQuantity  = 290
+	columnUnitPrice = 355
+	columnDiscount  = 405
+	columnTaxRate   = 450
+	columnAmount    = marginRight
+)
+
+// InvoicePDF writes the invoice as a PDF document with the subsets of the fonts embedded.
+// It returns an error wrapping ErrUnsupportedText if the fonts lack any character of the invoice.
+func InvoicePDF(w io.Writer, invoice *repo.Invoice) error {
+	doc := newPDFDocument()
+	doc.newPage()
+
+	doc.text(marginLeft, doc.y, fontBold, 18, title(invoice))
+	doc.y -= 36
+
+	// The issuer and the customer are side by side.
+	top := doc.y
+	columns := []struct {
+		x       float64
+		heading string
+		party   repo.Party
+	}{
+		{marginLeft, "From", invoice.Issuer},
+		{pageWidth / 2, "Bill to", invoice.Customer},
+	}
+	bottom := top
+
+	for _, column := range columns {
+		if column.party.Name == "" {
+			continue
+		}
+
+		doc.y = top
+		doc.text(column.x, doc.y, fontBold, 10, column.heading)
+		doc.y -= 14
+		lines := wrapText(column.party.Name, fontRegular, 10, pageWidth/2-marginLeft-20)
+		lines = append(lines, wrapText(column.party.Address, fontRegular, 10, pageWidth/2-marginLeft-20)...)
+
+		if column.party.TaxID != "" {
+			lines = append(lines, "Tax ID: "+column.party.TaxID)
+		}
+
+		for _, line := range lines {
+			doc.text(column.x, doc.y, fontRegular, 10, line)
+			doc.y -= 13
+		}
+
+		if doc.y < bottom {
+			bottom = doc.y
+		}
+	}
+
+	doc.y = bottom - 10
+	details := [][2]string{
+		{"Issue date", formatDate(invoice.IssueDate)},
+		{"Due date", formatDate(invoice.DueDate)},
+		{"Order", strconv.FormatInt(invoice.OrderID, 10)},
+	}
+
+	if invoice.PaymentTerms != "" {
+		details = append(details, [2]string{"Payment terms", invoice.PaymentTerms})
+	}
+
+	for _, detail := range details {
+		doc.text(marginLeft, doc.y, fontBold, 10, detail[0])
+		doc.text(marginLeft+90, doc.y, fontRegular, 10, detail[1])
+		doc.y -= 14
+	}
+
+	doc.y -= 16
+	doc.tableHeader()
+
+	for _, line := range invoice.Lines {
+		titleLines := wrapText(line.Title, fontRegular, 9, titleWidth)
+
+		if len(titleLines) == 0 {
+			titleLines = []string{""}
+		}
+
+		noteLines := wrapText(line.Notes, fontRegular, 8, titleWidth)
+		height := float64(len(titleLines))*12 + float64(len(noteLines))*10 + 4
+
+		if doc.y-height < marginBottom {
+			doc.newPage()
+			doc.tableHeader()
+		}
+
+		doc.text(columnPosition, doc.y, fontRegular, 9, strconv.FormatInt(line.Position, 10))
+		doc.textRight(columnQuantity, doc.y, fontRegular, 9, strconv.FormatInt(line.Quantity, 10))
+		doc.textRight(columnUnitPrice, doc.y, fontRegular, 9, line.UnitPrice.String())
+		doc.textRight(columnDiscount, doc.y, fontRegular, 9, line.Discount.String())
+		doc.textRight(columnTaxRate, doc.y, fontRegular, 9, line.TaxRate.String())
+		doc.textRight(columnAmount, doc.y, fontRegular, 9, line.NetAmount.String())
+
+		for _, text := range titleLines {
+			doc.text(columnTitle, doc.y, fontRegular, 9, text)
+			doc.y -= 12
+		}
+
+		for _, text := range noteLines {
+			doc.text(columnTitle, doc.y+2, fontRegular, 8, text)
+			doc.y -= 10
+		}
+
+		doc.y -= 4
+	}
+
+	if doc.y-50 < marginBottom {
+		doc.newPage()
+	}
+
+	doc.line(columnTaxRate-60, doc.y+8, marginRight, doc.y+8)
+	doc.y -= 6
+	totals := []struct {
+		label  string
+		amount repo.Money
+		font   string
+	}{
+		{"Subtotal, " + invoice.Currency, invoice.Subtotal, fontRegular},
+		{"Tax, " + invoice.Currency, invoice.Tax, fontRegular},
+		{"Total, " + invoice.Currency, invoice.Total, fontBold},
+	}
+
+	for _, total := range totals {
+		doc.textRight(columnTaxRate, doc.y, total.font, 10, total.label)
+		doc.textRight(columnAmount, doc.y, total.font, 10, total.amount.String())
+		doc.y -= 14
+	}
+
+	for i, page := range doc.pages {
+		footer := fmt.Sprintf("%s, page %d of %d", title(invoice), i+1, len(doc.pages))
+		doc.writeText(page, marginLeft, marginBottom-30, fontRegular, 8, footer)
+	}
+
+	data, err := doc.bytes(title(invoice))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
+
+// pdfDocument lays out the content of the pages top down.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	// y is the baseline of the next line of text on the current page.
+	y float64
+	// used maps the glyphs used by every font to the characters they show.
+	used map[string]map[uint16]rune
+	// err is the first character the fonts lack.
+	err error
+}
+
+func newPDFDocument() *pdfDocument {
+	doc := &pdfDocument{used: make(map[string]map[uint16]rune)}
+
+	for _, name := range fontNames {
+		doc.used[name] = make(map[uint16]rune)
+	}
+
+	return doc
+}
+
+func (doc *pdfDocument) newPage() {
+	doc.page = new(bytes.Buffer)
+	doc.pages = append(doc.pages, doc.page)
+	doc.y = marginTop
+}
+
+func (doc *pdfDocument) tableHeader() {
+	doc.text(columnPosition, doc.y, fontBold, 9, "#")
+	doc.text(columnTitle, doc.y, fontBold, 9, "Service")
+	doc.textRight(columnQuantity, doc.y, fontBold, 9, "Qty")
+	doc.textRight(columnUnitPrice, doc.y, fontBold, 9, "Unit price")
+	doc.textRight(columnDiscount, doc.y, fontBold, 9, "Disc., %")
+	doc.textRight(columnTaxRate, doc.y, fontBold, 9, "Tax, %")
+	doc.textRight(columnAmount, doc.y, fontBold, 9, "Amount")
+	doc.line(marginLeft, doc.y-4, marginRight, doc.y-4)
+	doc.y -= 18
+}
+
+func (doc *pdfDocument) text(x, y float64, font string, size float64, text string) {
+	doc.writeText(doc.page, x, y, font, size, text)
+}
+
+// textRight writes the text ending at x.
+func (doc *pdfDocument) textRight(x, y float64, font string, size float64, text string) {
+	doc.writeText(doc.page, x-textWidth(text, font, size), y, font, size, text)
+}
+
+func (doc *pdfDocument) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(doc.page, "0.5 w %s %s m %s %s l S\n",
+		formatNumber(x1), formatNumber(y1), formatNumber(x2), formatNumber(y2))
+}
+
+func (doc *pdfDocument) writeText(page *bytes.Buffer, x, y float64, font string, size float64, text string) {
+	if text == "" {
+		return
+	}
+
+	fmt.Fprintf(page, "BT /%s %s Tf %s %s Td %s Tj ET\n",
+		font, formatNumber(size), formatNumber(x), formatNumber(y), doc.encode(font, text))
+}
+
+// encode returns the text as a PDF hex string of the IDs of the glyphs of the font
+// and remembers the glyphs used.
+func (doc *pdfDocument) encode(font, text string) string {
+	ids := make([]byte, 0, 2*len(text))
+
+	for _, r := range text {
+		id := glyphID(font, r)
+
+		if id == 0 && doc.err == nil {
+			doc.err = fmt.Errorf("%w: no glyph for %q (U+%04X)", ErrUnsupportedText, r, r)
+		}
+
+		doc.used[font][id] = r
+		ids = append(ids, byte(id>>8), byte(id))
+	}
+
+	return "<" + hex.EncodeToString(ids) + ">"
+}
+
+// bytes returns the PDF file with the pages. The objects are numbered as follows:
+// the catalog, the page tree, the document information, the objects of every font
+// and then the content stream and the page object of every page.
+func (doc *pdfDocument) bytes(title string) ([]byte, error) {
+	if doc.err != nil {
+		return nil, doc.err
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		fmt.Sprintf("<< /Title %s /Producer (restApp) >>", textString(title)),
+	}
+	resources := make([]string, 0, len(fontNames))
+
+	for _, name := range fontNames {
+		fontObjects, err := fontObjects(fonts[name], doc.used[name], len(objects)+1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, fmt.Sprintf("/%s %d 0 R", name, len(objects)+1))
+		objects = append(objects, fontObjects...)
+	}
+
+	kids := make([]string, 0, len(doc.pages))
+
+	for _, page := range doc.pages {
+		content, err := flateStream("", page.Bytes())
+
+		if err != nil {
+			return nil, err
+		}
+
+		contentID := len(objects) + 1
+		objects = append(objects, content,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+				"/Resources << /Font << %s >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, strings.Join(resources, " "), contentID))
+		kids = append(kids, fmt.Sprintf("%d 0 R", contentID+1))
+	}
+
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "), len(kids))
+
+	var file bytes.Buffer
+	// The binary comment tells the tools the file isn't a text one.
+	file.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+
+	for i, object := range objects {
+		offsets[i] = file.Len()
+		fmt.Fprintf(&file, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := file.Len()
+	fmt.Fprintf(&file, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&file, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&file, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, xref)
+
+	return file.Bytes(), nil
+}
+
+// fontObjects returns the objects embedding the subset of the font with the used glyphs
+// numbered from the first ID: the Type0 font, its descendant CID font, the font descriptor,
+// the font file and the map of the glyphs to the characters copied from the document.
+func fontObjects(font *trueTypeFont, used map[uint16]rune, first int) ([]string, error) {
+	ids := make([]int, 0, len(used))
+
+	for id := range used {
+		ids = append(ids, int(id))
+	}
+
+	sort.Ints(ids)
+
+	// The subsets are told apart by the tags prefixed to the names of the fonts.
+	hash := fnv.New64a()
+	widths := make([]string, 0, len(ids))
+	var unicode bytes.Buffer
+
+	for i, id := range ids {
+		fmt.Fprintf(hash, "%d,", id)
+		widths = append(widths, fmt.Sprintf("%d [%d]", id, int(math.Round(font.width(uint16(id))))))
+
+		// The blocks of the map contain at most 100 entries.
+		if i%100 == 0 {
+			if i > 0 {
+				unicode.WriteString("endbfchar\n")
+			}
+
+			count := len(ids) - i
+
+			if count > 100 {
+				count = 100
+			}
+
+			fmt.Fprintf(&unicode, "%d beginbfchar\n", count)
+		}
+
+		fmt.Fprintf(&unicode, "<%04X> %s\n", id, utf16Hex(string(used[uint16(id)])))
+	}
+
+	if len(ids) > 0 {
+		unicode.WriteString("endbfchar\n")
+	}
+
+	sum := hash.Sum64()
+	tag := make([]byte, 6)
+
+	for i := range tag {
+		tag[i] = byte('A' + sum%26)
+		sum /= 26
+	}
+
+	name := string(tag) + "+" + font.name
+	subset := font.subset(used)
+	file, err := flateStream(fmt.Sprintf(" /Length1 %d", len(subset)), subset)
+
+	if err != nil {
+		return nil, err
+	}
+
+	toUnicode, err := flateStream("", []byte(
+		"/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n"+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n"+
+			"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n"+
+			"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n"+
+			unicode.String()+
+			"endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The usual approximation of the vertical stem width by the weight of the font.
+	stemV := 10 + 220*(font.weight-50)/900
+
+	return []string{
+		fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+			"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", name, first+1, first+4),
+		fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+			name, first+2, strings.Join(widths, " ")),
+		fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] "+
+			"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV %d /FontFile2 %d 0 R >>",
+			name, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]),
+			font.scale(font.bbox[3]), font.scale(font.ascent), font.scale(font.descent),
+			font.scale(font.capHeight), stemV, first+3),
+		file,
+		toUnicode,
+	}, nil
+}
+
+// flateStream returns the stream object with the compressed data
+// and the extra entries of its dictionary.
+func flateStream(extra string, data []byte) (string, error) {
+	var content bytes.Buffer
+	compressor := zlib.NewWriter(&content)
+
+	if _, err := compressor.Write(data); err != nil {
+		return "", err
+	}
+
+	if err := compressor.Close(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("<< /Length %d /Filter /FlateDecode%s >>\nstream\n%s\nendstream",
+		content.Len(), extra, content.String()), nil
+}
+
+// textString returns the text as a PDF hex string in UTF-16 the documents information takes.
+func textString(text string) string {
+	return "<FEFF" + utf16Hex(text)[1:]
+}
+
+// utf16Hex returns the text as a hex string in UTF-16BE.
+func utf16Hex(text string) string {
+	units := utf16.Encode([]rune(text))
+	encoded := make([]byte, 0, 2*len(units))
+
+	for _, unit := range units {
+		encoded = append(encoded, byte(unit>>8), byte(unit))
+	}
+
+	return "<" + strings.ToUpper(hex.EncodeToString(encoded)) + ">"
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// glyphID returns the ID of the glyph of the font showing the character
+// or 0 if the font has no such glyph. The control characters are shown as spaces.
+func glyphID(font string, r rune) uint16 {
+	if r < ' ' {
+		r = ' '
+	}
+
+	return fonts[font].glyphs[r]
+}
+
+// textWidth returns the width of the text in points.
+func textWidth(text, font string, size float64) float64 {
+	total := 0.0
+
+	for _, r := range text {
+		total += fonts[font].width(glyphID(font, r))
+	}
+
+	return total * size / 1000
+}
+
+// wrapText splits the text into the lines fitting into the width breaking them
+// between the words. The words longer than the width are broken anywhere.
+func wrapText(text, font string, size, width float64) []string {
+	lines := make([]string, 0, 1)
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if textWidth(candidate, font, size) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		line = ""
+
+		for _, r := range word {
+			if line != "" && textWidth(line+string(r), font, size) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+
+			line += string(r)
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -0,0 +1,21 @@
+// Package render renders the invoices to the HTML and PDF documents
+// without any external tools or services.
+package render
+
+import (
+	"fmt"
+	"restApp/repo"
+	"time"
+)
+
+// dateLayout is the layout of the dates in the documents.
+const dateLayout = "2006-01-02"
+
+// title returns the title of the invoice document.
+func title(invoice *repo.Invoice) string {
+	return fmt.Sprintf("Invoice No. %06d", invoice.Number)
+}
+
+func formatDate(date time.Time) string {
+	return date.Format(dateLayout)
+}
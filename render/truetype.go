@@ -0,0 +1,468 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"restApp/assets"
+	"sort"
+)
+
+// trueTypeFont is a TrueType font parsed as far as measuring the text
+// and embedding the subsets of the font into the PDF documents require.
+type trueTypeFont struct {
+	// name is the PostScript name of the font.
+	name   string
+	tables map[string][]byte
+	// The metrics are in the font units.
+	unitsPerEm int
+	bbox       [4]int
+	ascent     int
+	descent    int
+	capHeight  int
+	weight     int
+	longLoca   bool
+	// advances are the widths of the glyphs by their IDs.
+	advances []int
+	// glyphs maps the characters to the IDs of their glyphs.
+	glyphs map[rune]uint16
+}
+
+// errTruncatedFont is returned when a table of the font is shorter than its format requires.
+var errTruncatedFont = errors.New("the font is truncated")
+
+// mustLoadFont parses the font from the assets and panics if it can't.
+func mustLoadFont(path, name string) *trueTypeFont {
+	data, err := assets.FS.ReadFile(path)
+
+	if err != nil {
+		panic(err)
+	}
+
+	font, err := parseTrueType(name, data)
+
+	if err != nil {
+		panic(fmt.Errorf("couldn't parse the font %s: %w", path, err))
+	}
+
+	return font
+}
+
+// parseTrueType parses the font file. The name is the PostScript name of the font.
+func parseTrueType(name string, data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errTruncatedFont
+	}
+
+	font := &trueTypeFont{name: name, tables: make(map[string][]byte)}
+	count := int(u16(data, 4))
+
+	if len(data) < 12+16*count {
+		return nil, errTruncatedFont
+	}
+
+	for i := 0; i < count; i++ {
+		record := data[12+16*i:]
+		offset, length := int(u32(record, 8)), int(u32(record, 12))
+
+		if offset+length > len(data) {
+			return nil, errTruncatedFont
+		}
+
+		font.tables[string(record[:4])] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if _, ok := font.tables[tag]; !ok {
+			return nil, fmt.Errorf("the font has no %s table", tag)
+		}
+	}
+
+	head, hhea, maxp := font.tables["head"], font.tables["hhea"], font.tables["maxp"]
+
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errTruncatedFont
+	}
+
+	font.unitsPerEm = int(u16(head, 18))
+	font.bbox = [4]int{i16(head, 36), i16(head, 38), i16(head, 40), i16(head, 42)}
+	font.longLoca = u16(head, 50) == 1
+	font.ascent = i16(hhea, 4)
+	font.descent = i16(hhea, 6)
+	font.weight = 400
+
+	if font.unitsPerEm == 0 {
+		return nil, errors.New("the font has no units per em")
+	}
+
+	// The cap height is known to the version 2 of the OS/2 table and the later ones.
+	if os2 := font.tables["OS/2"]; len(os2) >= 6 {
+		font.weight = int(u16(os2, 4))
+
+		if u16(os2, 0) >= 2 && len(os2) >= 90 {
+			font.capHeight = i16(os2, 88)
+		}
+	}
+
+	numGlyphs := int(u16(maxp, 4))
+	metrics := int(u16(hhea, 34))
+	locaSize := 2
+
+	if font.longLoca {
+		locaSize = 4
+	}
+
+	if metrics == 0 || metrics > numGlyphs || len(font.tables["hmtx"]) < 4*metrics ||
+		len(font.tables["loca"]) < locaSize*(numGlyphs+1) {
+		return nil, errTruncatedFont
+	}
+
+	// The glyphs beyond the metrics have the width of the last one.
+	font.advances = make([]int, numGlyphs)
+
+	for id := range font.advances {
+		if id < metrics {
+			font.advances[id] = int(u16(font.tables["hmtx"], 4*id))
+		} else {
+			font.advances[id] = font.advances[metrics-1]
+		}
+	}
+
+	glyphs, err := parseCmap(font.tables["cmap"], numGlyphs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	font.glyphs = glyphs
+
+	// The fonts with the older OS/2 tables have the cap height of the H.
+	if font.capHeight == 0 {
+		font.capHeight = font.ascent
+
+		if outline := font.glyph(font.glyphs['H']); len(outline) >= 10 {
+			font.capHeight = i16(outline, 8)
+		}
+	}
+
+	return font, nil
+}
+
+// parseCmap returns the Unicode mapping of the characters to the glyphs from the cmap table.
+// It prefers the table covering all the planes to the one covering only the basic one.
+func parseCmap(cmap []byte, numGlyphs int) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errTruncatedFont
+	}
+
+	var subtable []byte
+	count := int(u16(cmap, 2))
+
+	for i := 0; i < count && 12+8*i <= len(cmap); i++ {
+		platform, encoding := u16(cmap, 4+8*i), u16(cmap, 6+8*i)
+		offset := int(u32(cmap, 8+8*i))
+
+		if platform != 0 && (platform != 3 || encoding != 1 && encoding != 10) || offset+4 > len(cmap) {
+			continue
+		}
+
+		switch u16(cmap, offset) {
+		case 12:
+			subtable = cmap[offset:]
+
+		case 4:
+			if subtable == nil {
+				subtable = cmap[offset:]
+			}
+		}
+	}
+
+	if subtable == nil {
+		return nil, errors.New("the font has no Unicode cmap")
+	}
+
+	glyphs := make(map[rune]uint16)
+	add := func(r rune, id uint16) {
+		if id != 0 && int(id) < numGlyphs {
+			glyphs[r] = id
+		}
+	}
+
+	if u16(subtable, 0) == 12 {
+		if len(subtable) < 16 {
+			return nil, errTruncatedFont
+		}
+
+		groups := int(u32(subtable, 12))
+
+		if len(subtable) < 16+12*groups {
+			return nil, errTruncatedFont
+		}
+
+		for i := 0; i < groups; i++ {
+			group := subtable[16+12*i:]
+			start, end, id := u32(group, 0), u32(group, 4), u32(group, 8)
+
+			for r := start; r <= end && r <= 0x10ffff; r++ {
+				add(rune(r), uint16(id+r-start))
+			}
+		}
+
+		return glyphs, nil
+	}
+
+	if len(subtable) < 14 {
+		return nil, errTruncatedFont
+	}
+
+	segments := int(u16(subtable, 6)) / 2
+	ends := 14
+	starts := ends + 2*segments + 2
+	deltas := starts + 2*segments
+	rangeOffsets := deltas + 2*segments
+
+	if len(subtable) < rangeOffsets+2*segments {
+		return nil, errTruncatedFont
+	}
+
+	for i := 0; i < segments; i++ {
+		start, end := int(u16(subtable, starts+2*i)), int(u16(subtable, ends+2*i))
+		delta := u16(subtable, deltas+2*i)
+		rangeOffset := int(u16(subtable, rangeOffsets+2*i))
+
+		// The last segment maps 0xFFFF to nothing.
+		for c := start; c <= end && c < 0xffff; c++ {
+			if rangeOffset == 0 {
+				add(rune(c), uint16(c)+delta)
+				continue
+			}
+
+			// The offset is relative to its own place in the table.
+			at := rangeOffsets + 2*i + rangeOffset + 2*(c-start)
+
+			if at+2 > len(subtable) {
+				continue
+			}
+
+			if id := u16(subtable, at); id != 0 {
+				add(rune(c), id+delta)
+			}
+		}
+	}
+
+	return glyphs, nil
+}
+
+// glyph returns the outline of the glyph, which is empty for the glyphs like the space.
+func (font *trueTypeFont) glyph(id uint16) []byte {
+	loca, glyf := font.tables["loca"], font.tables["glyf"]
+	var start, end int
+
+	if font.longLoca {
+		start, end = int(u32(loca, 4*int(id))), int(u32(loca, 4*int(id)+4))
+	} else {
+		start, end = 2*int(u16(loca, 2*int(id))), 2*int(u16(loca, 2*int(id)+2))
+	}
+
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+
+	return glyf[start:end]
+}
+
+// width returns the width of the glyph in thousandths of the font size.
+func (font *trueTypeFont) width(id uint16) float64 {
+	return float64(font.advances[id]) * 1000 / float64(font.unitsPerEm)
+}
+
+// scale converts the font units to thousandths of the font size.
+func (font *trueTypeFont) scale(value int) int {
+	return value * 1000 / font.unitsPerEm
+}
+
+// Flags of the components of the composite glyphs.
+const (
+	argsAreWords     = 0x0001
+	hasScale         = 0x0008
+	moreComponents   = 0x0020
+	hasXAndYScale    = 0x0040
+	hasTwoByTwoScale = 0x0080
+)
+
+// components returns the IDs of the glyphs the composite glyph is made of.
+func components(glyph []byte) []uint16 {
+	if len(glyph) < 10 || i16(glyph, 0) >= 0 {
+		return nil
+	}
+
+	var ids []uint16
+
+	for offset := 10; offset+4 <= len(glyph); {
+		flags := u16(glyph, offset)
+		ids = append(ids, u16(glyph, offset+2))
+		offset += 6
+
+		if flags&argsAreWords != 0 {
+			offset += 2
+		}
+
+		switch {
+		case flags&hasScale != 0:
+			offset += 2
+
+		case flags&hasXAndYScale != 0:
+			offset += 4
+
+		case flags&hasTwoByTwoScale != 0:
+			offset += 8
+		}
+
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+
+	return ids
+}
+
+// subset returns the font file with the outlines of the used glyphs only.
+// The rest of the glyphs keep their IDs and widths, so the text needs no other encoding.
+// The tables PDF readers don't need, such as cmap, are left out.
+func (font *trueTypeFont) subset(used map[uint16]rune) []byte {
+	kept := make(map[uint16]bool)
+	// The glyph 0 is shown for the missing characters and must always be present.
+	queue := []uint16{0}
+
+	for id := range used {
+		queue = append(queue, id)
+	}
+
+	for len(queue) > 0 {
+		id := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		if kept[id] || int(id) >= len(font.advances) {
+			continue
+		}
+
+		kept[id] = true
+		queue = append(queue, components(font.glyph(id))...)
+	}
+
+	var glyf bytes.Buffer
+	loca := make([]byte, 4*(len(font.advances)+1))
+
+	for id := range font.advances {
+		binary.BigEndian.PutUint32(loca[4*id:], uint32(glyf.Len()))
+
+		if kept[uint16(id)] {
+			glyf.Write(font.glyph(uint16(id)))
+
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+
+	binary.BigEndian.PutUint32(loca[4*len(font.advances):], uint32(glyf.Len()))
+
+	// The subset always has the long offsets of the glyphs.
+	head := append([]byte(nil), font.tables["head"]...)
+	binary.BigEndian.PutUint16(head[50:], 1)
+	tables := map[string][]byte{"head": head, "loca": loca, "glyf": glyf.Bytes()}
+
+	for _, tag := range []string{"hhea", "maxp", "hmtx", "cvt ", "fpgm", "prep"} {
+		if table, ok := font.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+
+	return writeTrueType(tables)
+}
+
+// writeTrueType returns the font file with the tables.
+func writeTrueType(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+
+	// The search fields speed up the binary search of the tables.
+	selector := 0
+
+	for 2<<selector <= len(tags) {
+		selector++
+	}
+
+	header := make([]byte, 12+16*len(tags))
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(len(tags)))
+	binary.BigEndian.PutUint16(header[6:], uint16(16<<selector))
+	binary.BigEndian.PutUint16(header[8:], uint16(selector))
+	binary.BigEndian.PutUint16(header[10:], uint16(16*len(tags)-16<<selector))
+
+	offset := len(header)
+	headOffset := 0
+
+	for i, tag := range tags {
+		table := tables[tag]
+		record := header[12+16*i:]
+
+		if tag == "head" {
+			// The checksum of the head table is computed without the adjustment.
+			binary.BigEndian.PutUint32(table[8:], 0)
+			headOffset = offset
+		}
+
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], checksum(table))
+		binary.BigEndian.PutUint32(record[8:], uint32(offset))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table)))
+		offset += (len(table) + 3) &^ 3
+	}
+
+	file := bytes.NewBuffer(header)
+
+	for _, tag := range tags {
+		file.Write(tables[tag])
+
+		for file.Len()%4 != 0 {
+			file.WriteByte(0)
+		}
+	}
+
+	data := file.Bytes()
+	binary.BigEndian.PutUint32(data[headOffset+8:], 0xb1b0afba-checksum(data))
+
+	return data
+}
+
+// checksum returns the sum of the big-endian words of the data padded with zeros.
+func checksum(data []byte) uint32 {
+	var sum uint32
+
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+
+	return sum
+}
+
+func u16(data []byte, offset int) uint16 {
+	return binary.BigEndian.Uint16(data[offset:])
+}
+
+func i16(data []byte, offset int) int {
+	return int(int16(u16(data, offset)))
+}
+
+func u32(data []byte, offset int) uint32 {
+	return binary.BigEndian.Uint32(data[offset:])
+}
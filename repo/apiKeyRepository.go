@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// APIKeyRepository represents a data repository for the API keys.
+type APIKeyRepository struct {
+	db    queryer
+	stmts *Statements
+}
+
+// Scripts used by the API key repository.
+const (
+	getAPIKeyByHashScript = "sql/api_keys/get_api_key_by_hash.sql"
+	getAllAPIKeysScript   = "sql/api_keys/get_all_api_keys.sql"
+	addAPIKeyScript       = "sql/api_keys/add_api_key.sql"
+	revokeAPIKeyScript    = "sql/api_keys/revoke_api_key.sql"
+)
+
+// GetAPIKeyByHash returns the key with the specified hash unless the key is revoked.
+func (repo *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getAPIKeyByHashScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := &APIKey{Hash: hash}
+	err = stmt.QueryRowContext(ctx, hash).Scan(&key.ID, &key.Name, pq.Array(&key.Roles),
+		&key.CustomerID, &key.CreatedAt, &key.RevokedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return key, nil
+}
+
+// GetAllAPIKeys returns all the keys including the revoked ones.
+func (repo *APIKeyRepository) GetAllAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getAllAPIKeysScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	keys := make([]*APIKey, 0)
+
+	for rows.Next() {
+		key := new(APIKey)
+		err = rows.Scan(&key.ID, &key.Name, pq.Array(&key.Roles),
+			&key.CustomerID, &key.CreatedAt, &key.RevokedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return keys, nil
+}
+
+// AddAPIKey adds a new key to the database
+// and fills the key with the stored data including its ID.
+func (repo *APIKeyRepository) AddAPIKey(ctx context.Context, key *APIKey) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, addAPIKeyScript)
+
+	if err != nil {
+		return err
+	}
+
+	row := stmt.QueryRowContext(ctx, key.Name, key.Hash, pq.Array(key.Roles), key.CustomerID)
+	err = row.Scan(&key.ID, &key.Name, pq.Array(&key.Roles),
+		&key.CustomerID, &key.CreatedAt, &key.RevokedAt)
+
+	return translateError(err)
+}
+
+// RevokeAPIKey revokes the key so it can't be used anymore.
+// It returns ErrNotFound if the key doesn't exist or is already revoked.
+func (repo *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int64) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, revokeAPIKeyScript)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, id)
+
+	if err != nil {
+		return translateError(err)
+	}
+
+	return checkAffected(result)
+}
+
+// NewAPIKeyRepo creates a new repository for the API keys.
+func NewAPIKeyRepo(db *sql.DB, stmts *Statements) *APIKeyRepository {
+	return &APIKeyRepository{db, stmts}
+}
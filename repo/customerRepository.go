@@ -1,109 +1,185 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
-	"restApp/assets"
 )
 
 // CustomerRepository represents a data repository and implements CRUD methods for customers.
 type CustomerRepository struct {
-	db *sql.DB
+	db    queryer
+	stmts *Statements
 }
 
+// Scripts used by the customer repository.
+const (
+	getAllCustomersScript = "sql/customers/get_all_customers.sql"
+	countCustomersScript  = "sql/customers/count_customers.sql"
+	getCustomerByIDScript = "sql/customers/get_customer_by_id.sql"
+	addCustomerScript     = "sql/customers/add_customer.sql"
+	updateCustomerScript  = "sql/customers/update_customer.sql"
+	deleteCustomerScript  = "sql/customers/delete_customer.sql"
+)
+
 // GetCustomerByID returns a single customer under the specified ID.
-func (repo *CustomerRepository) GetCustomerByID(id int64) (*Customer, error) {
-	script, err := assets.Asset("sql/customers/get_customer_by_id.sql")
+func (repo *CustomerRepository) GetCustomerByID(ctx context.Context, id int64) (*Customer, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getCustomerByIDScript)
 
 	if err != nil {
 		return nil, err
 	}
 
-	row := repo.db.QueryRow(string(script), id)
+	row := stmt.QueryRowContext(ctx, id, scopeArg(ctx))
 	customer := new(Customer)
-	err = row.Scan(&customer.ID, &customer.Name, &customer.Address,
-		&customer.TaxID, &customer.Email, &customer.PhoneNumber)
+	err = row.Scan(&customer.ID, &customer.Name, &customer.Address, &customer.TaxID,
+		&customer.Email, &customer.PhoneNumber, &customer.Jurisdiction)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return customer, nil
 }
 
-// GetAllCustomers returns a set of all customers from the database.
-func (repo *CustomerRepository) GetAllCustomers() ([]*Customer, error) {
-	script, err := assets.Asset("sql/customers/get_all_customers.sql")
+// customerColumns contains the columns customers can be sorted by.
+var customerColumns = map[string]string{
+	"id":           "c.id",
+	"name":         "c.company_name",
+	"address":      "c.company_address",
+	"tax_id":       "c.tax_id",
+	"email":        "c.email",
+	"phone_number": "c.phone_number",
+	"jurisdiction": "c.jurisdiction",
+}
+
+// GetAllCustomers returns a single page of customers satisfying the filter
+// and the total number of such customers in the database.
+func (repo *CustomerRepository) GetAllCustomers(ctx context.Context, filter *CustomerFilter, params *ListParams) ([]*Customer, int64, error) {
+	script := repo.stmts.script(getAllCustomersScript)
+	countScript := repo.stmts.script(countCustomersScript)
+
+	query := new(listQuery)
+
+	if id, ok := CustomerScope(ctx); ok {
+		query.where("c.id = ?", id)
+	}
+
+	if filter.Name != "" {
+		query.where("c.company_name ILIKE ?", likePattern(filter.Name))
+	}
+
+	if filter.Address != "" {
+		query.where("c.company_address ILIKE ?", likePattern(filter.Address))
+	}
+
+	if filter.TaxID != "" {
+		query.where("c.tax_id = ?", filter.TaxID)
+	}
+
+	if filter.Email != "" {
+		query.where("LOWER(c.email) = LOWER(?)", filter.Email)
+	}
+
+	if filter.PhoneNumber != "" {
+		query.where("c.phone_number = ?", filter.PhoneNumber)
+	}
+
+	statement, args, err := query.list(script, "c.id", customerColumns, params)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	rows, err := repo.db.Query(string(script))
+	rows, err := repo.stmts.query(ctx, repo.db, getAllCustomersScript, statement, args...)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, translateError(err)
 	}
+	defer rows.Close()
 
 	customers := make([]*Customer, 0)
 
 	for rows.Next() {
 		customer := new(Customer)
 
-		err = rows.Scan(&customer.ID, &customer.Name, &customer.Address,
-			&customer.TaxID, &customer.Email, &customer.PhoneNumber)
+		err = rows.Scan(&customer.ID, &customer.Name, &customer.Address, &customer.TaxID,
+			&customer.Email, &customer.PhoneNumber, &customer.Jurisdiction)
 
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		customers = append(customers, customer)
 	}
 
-	return customers, nil
+	if err = rows.Err(); err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	var total int64
+	statement, args = query.count(countScript)
+	err = repo.stmts.queryRow(ctx, repo.db, countCustomersScript, statement, args...).Scan(&total)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return customers, total, nil
 }
 
-// AddCustomer adds a new customer to the database.
-func (repo *CustomerRepository) AddCustomer(customer *Customer) error {
-	script, err := assets.Asset("sql/customers/add_customer.sql")
+// AddCustomer adds a new customer to the database
+// and fills the customer with the stored data including its ID.
+func (repo *CustomerRepository) AddCustomer(ctx context.Context, customer *Customer) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, addCustomerScript)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.db.Exec(string(script), customer.Name, customer.Address,
-		customer.TaxID, customer.Email, customer.PhoneNumber)
+	row := stmt.QueryRowContext(ctx, customer.Name, customer.Address,
+		customer.TaxID, customer.Email, customer.PhoneNumber, customer.Jurisdiction)
+	err = row.Scan(&customer.ID, &customer.Name, &customer.Address, &customer.TaxID,
+		&customer.Email, &customer.PhoneNumber, &customer.Jurisdiction)
 
-	return err
+	return translateError(err)
 }
 
 // UpdateCustomer updates the customer in the database.
-func (repo *CustomerRepository) UpdateCustomer(customer *Customer) error {
-	script, err := assets.Asset("sql/customers/update_customer.sql")
+func (repo *CustomerRepository) UpdateCustomer(ctx context.Context, customer *Customer) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, updateCustomerScript)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.db.Exec(string(script), customer.ID, customer.Name, customer.Address,
-		customer.TaxID, customer.Email, customer.PhoneNumber)
+	result, err := stmt.ExecContext(ctx, customer.ID, customer.Name, customer.Address,
+		customer.TaxID, customer.Email, customer.PhoneNumber, customer.Jurisdiction, scopeArg(ctx))
+
+	if err != nil {
+		return translateError(err)
+	}
 
-	return err
+	return checkAffected(result)
 }
 
 // DeleteCustomer deletes the customer from the database.
-func (repo *CustomerRepository) DeleteCustomer(id int64) error {
-	script, err := assets.Asset("sql/customers/delete_customer.sql")
+func (repo *CustomerRepository) DeleteCustomer(ctx context.Context, id int64) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, deleteCustomerScript)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.db.Exec(string(script), id)
+	result, err := stmt.ExecContext(ctx, id, scopeArg(ctx))
+
+	if err != nil {
+		return translateError(err)
+	}
 
-	return err
+	return checkAffected(result)
 }
 
 // NewCustomerRepo creates a new repository for customers.
-func NewCustomerRepo(db *sql.DB) *CustomerRepository {
-	return &CustomerRepository{db}
+func NewCustomerRepo(db *sql.DB, stmts *Statements) *CustomerRepository {
+	return &CustomerRepository{db, stmts}
 }
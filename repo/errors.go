@@ -0,0 +1,205 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrNotFound is returned when the requested entry doesn't exist.
+	ErrNotFound = errors.New("entry not found")
+	// ErrUniqueViolation is returned when the entry has the same value
+	// of the unique field as some other entry.
+	ErrUniqueViolation = errors.New("unique constraint violation")
+	// ErrForeignKeyViolation is returned when the entry refers to a nonexistent entry
+	// or the entry being deleted is referred by some other entries.
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrCheckViolation is returned when the data of the entry
+	// doesn't satisfy the constraints of the database schema.
+	ErrCheckViolation = errors.New("check constraint violation")
+	// ErrUnavailable is returned when the database can't be accessed.
+	ErrUnavailable = errors.New("database unavailable")
+	// ErrTxConflict is returned when the transaction is aborted
+	// due to a serialization failure or a deadlock with a concurrent one.
+	ErrTxConflict = errors.New("transaction conflict")
+	// ErrCanceled is returned when the operation is canceled
+	// because its context is done.
+	ErrCanceled = errors.New("operation canceled")
+	// ErrNoExchangeRate is returned when the amounts can't be converted
+	// to the currency because there is no exchange rate effective on the date.
+	ErrNoExchangeRate = errors.New("no exchange rate")
+)
+
+// PostgreSQL error codes and classes.
+// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pqUniqueViolation       = "23505"
+	pqForeignKeyViolation   = "23503"
+	pqCheckViolation        = "23514"
+	pqNotNullViolation      = "23502"
+	pqStringDataTruncation  = "22001"
+	pqNumericOutOfRange     = "22003"
+	pqInvalidDatetimeFormat = "22007"
+	pqDatetimeOutOfRange    = "22008"
+	pqSerializationFailure  = "40001"
+	pqDeadlockDetected      = "40P01"
+	pqQueryCanceled         = "57014"
+
+	pqClassConnectionException   = "08"
+	pqClassInsufficientResources = "53"
+	pqClassOperatorIntervention  = "57"
+)
+
+// constraintFields maps the names of the schema constraints to the fields they restrict.
+var constraintFields = map[string]string{
+	"customers_tax_id_key":                "tax_id",
+	"customers_email_key":                 "email",
+	"customers_phone_number_key":          "phone_number",
+	"services_title_key":                  "title",
+	"orders_customer_id_fkey":             "customer_id",
+	"orders_to_services_order_id_fkey":    "order_id",
+	"orders_to_services_service_id_fkey":  "service_id",
+	"orders_to_services_quantity_check":   "quantity",
+	"orders_to_services_unit_price_check": "unit_price",
+	"orders_to_services_discount_check":   "discount",
+	"orders_to_services_tax_rate_check":   "tax_rate",
+	"tax_rates_category_jurisdiction_key": "category",
+	"tax_rates_rate_check":                "rate",
+	"exchange_rates_rate_check":           "rate",
+	"exchange_rates_currencies_check":     "quote_currency",
+	"exchange_rates_currencies_date_key":  "effective_date",
+	"invoices_order_id_key":               "order_id",
+	"invoices_due_date_check":             "due_date",
+}
+
+// columnFields maps the names of the table columns
+// to the names of the model fields if they differ.
+var columnFields = map[string]string{
+	"company_name":        "name",
+	"company_address":     "address",
+	"service_description": "description",
+	"contract_date":       "date",
+}
+
+// ConstraintError is returned when the operation violates a constraint of the database schema.
+// It wraps one of ErrUniqueViolation, ErrForeignKeyViolation and ErrCheckViolation.
+type ConstraintError struct {
+	Err        error
+	Constraint string
+	// Field is the name of the model field restricted by the constraint, if known.
+	Field   string
+	Message string
+}
+
+func (err *ConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Err, err.Message)
+}
+
+// Unwrap returns the sentinel error describing the kind of the violation.
+func (err *ConstraintError) Unwrap() error {
+	return err.Err
+}
+
+// translateError converts the error returned by the database driver
+// into one of the sentinel errors of the package. The errors that can't be
+// converted are returned as is.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %v", ErrCanceled, err)
+	}
+
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) {
+		return translatePqError(pqErr)
+	}
+
+	var netErr net.Error
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	return err
+}
+
+func translatePqError(err *pq.Error) error {
+	var kind error
+
+	switch err.Code {
+	case pqUniqueViolation:
+		kind = ErrUniqueViolation
+
+	case pqForeignKeyViolation:
+		kind = ErrForeignKeyViolation
+
+	case pqCheckViolation, pqNotNullViolation, pqStringDataTruncation,
+		pqNumericOutOfRange, pqInvalidDatetimeFormat, pqDatetimeOutOfRange:
+		kind = ErrCheckViolation
+
+	case pqSerializationFailure, pqDeadlockDetected:
+		return fmt.Errorf("%w: %v", ErrTxConflict, err)
+
+	// The query is canceled by the driver when its context is done.
+	case pqQueryCanceled:
+		return fmt.Errorf("%w: %v", ErrCanceled, err)
+
+	default:
+		switch err.Code.Class() {
+		case pqClassConnectionException, pqClassInsufficientResources,
+			pqClassOperatorIntervention:
+			return fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+
+		return err
+	}
+
+	field, ok := constraintFields[err.Constraint]
+
+	if !ok {
+		field = strings.TrimSpace(err.Column)
+
+		if name, ok := columnFields[field]; ok {
+			field = name
+		}
+	}
+
+	return &ConstraintError{
+		Err:        kind,
+		Constraint: err.Constraint,
+		Field:      field,
+		Message:    err.Message,
+	}
+}
+
+// checkAffected returns ErrNotFound if the statement
+// didn't affect any entry in the database.
+func checkAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return translateError(err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
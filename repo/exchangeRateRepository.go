@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ExchangeRateRepository represents a data repository and implements CRUD methods for exchange rates.
+type ExchangeRateRepository struct {
+	db    queryer
+	stmts *Statements
+}
+
+// Scripts used by the exchange rate repository.
+const (
+	getAllExchangeRatesScript = "sql/exchange_rates/get_all_exchange_rates.sql"
+	countExchangeRatesScript  = "sql/exchange_rates/count_exchange_rates.sql"
+	getExchangeRateByIDScript = "sql/exchange_rates/get_exchange_rate_by_id.sql"
+	addExchangeRateScript     = "sql/exchange_rates/add_exchange_rate.sql"
+	updateExchangeRateScript  = "sql/exchange_rates/update_exchange_rate.sql"
+	deleteExchangeRateScript  = "sql/exchange_rates/delete_exchange_rate.sql"
+)
+
+// GetExchangeRateByID returns a single exchange rate under the specified ID.
+func (repo *ExchangeRateRepository) GetExchangeRateByID(ctx context.Context, id int64) (*ExchangeRate, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getExchangeRateByIDScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	row := stmt.QueryRowContext(ctx, id)
+	rate := new(ExchangeRate)
+	err = row.Scan(&rate.ID, &rate.BaseCurrency, &rate.QuoteCurrency,
+		&rate.Rate, &rate.EffectiveDate)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return rate, nil
+}
+
+// exchangeRateColumns contains the columns exchange rates can be sorted by.
+var exchangeRateColumns = map[string]string{
+	"id":             "er.id",
+	"base_currency":  "er.base_currency",
+	"quote_currency": "er.quote_currency",
+	"effective_date": "er.effective_date",
+}
+
+// GetAllExchangeRates returns a single page of exchange rates satisfying the filter
+// and the total number of such rates in the database.
+func (repo *ExchangeRateRepository) GetAllExchangeRates(ctx context.Context, filter *ExchangeRateFilter, params *ListParams) ([]*ExchangeRate, int64, error) {
+	script := repo.stmts.script(getAllExchangeRatesScript)
+	countScript := repo.stmts.script(countExchangeRatesScript)
+
+	query := new(listQuery)
+
+	if filter.BaseCurrency != "" {
+		query.where("er.base_currency = ?", filter.BaseCurrency)
+	}
+
+	if filter.QuoteCurrency != "" {
+		query.where("er.quote_currency = ?", filter.QuoteCurrency)
+	}
+
+	if filter.DateFrom != nil {
+		query.where("er.effective_date >= ?", *filter.DateFrom)
+	}
+
+	if filter.DateTo != nil {
+		query.where("er.effective_date <= ?", *filter.DateTo)
+	}
+
+	statement, args, err := query.list(script, "er.id", exchangeRateColumns, params)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := repo.stmts.query(ctx, repo.db, getAllExchangeRatesScript, statement, args...)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	defer rows.Close()
+
+	rates := make([]*ExchangeRate, 0)
+
+	for rows.Next() {
+		rate := new(ExchangeRate)
+		err = rows.Scan(&rate.ID, &rate.BaseCurrency, &rate.QuoteCurrency,
+			&rate.Rate, &rate.EffectiveDate)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	var total int64
+	statement, args = query.count(countScript)
+	err = repo.stmts.queryRow(ctx, repo.db, countExchangeRatesScript, statement, args...).Scan(&total)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return rates, total, nil
+}
+
+// AddExchangeRate adds a new exchange rate to the database
+// and fills the rate with the stored data including its ID.
+func (repo *ExchangeRateRepository) AddExchangeRate(ctx context.Context, rate *ExchangeRate) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, addExchangeRateScript)
+
+	if err != nil {
+		return err
+	}
+
+	row := stmt.QueryRowContext(ctx, rate.BaseCurrency, rate.QuoteCurrency,
+		rate.Rate, rate.EffectiveDate)
+	err = row.Scan(&rate.ID, &rate.BaseCurrency, &rate.QuoteCurrency,
+		&rate.Rate, &rate.EffectiveDate)
+
+	return translateError(err)
+}
+
+// UpdateExchangeRate updates the exchange rate in the database.
+func (repo *ExchangeRateRepository) UpdateExchangeRate(ctx context.Context, rate *ExchangeRate) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, updateExchangeRateScript)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, rate.ID, rate.BaseCurrency, rate.QuoteCurrency,
+		rate.Rate, rate.EffectiveDate)
+
+	if err != nil {
+		return translateError(err)
+	}
+
+	return checkAffected(result)
+}
+
+// DeleteExchangeRate deletes the exchange rate from the database.
+func (repo *ExchangeRateRepository) DeleteExchangeRate(ctx context.Context, id int64) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, deleteExchangeRateScript)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, id)
+
+	if err != nil {
+		return translateError(err)
+	}
+
+	return checkAffected(result)
+}
+
+// NewExchangeRateRepo creates a new repository for exchange rates.
+func NewExchangeRateRepo(db *sql.DB, stmts *Statements) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db, stmts}
+}
@@ -0,0 +1,285 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// InvoiceRepository represents a data repository for invoices.
+// The invoices are only issued and read, they are never changed or deleted.
+type InvoiceRepository struct {
+	db    queryer
+	stmts *Statements
+}
+
+// Scripts used by the invoice repository.
+const (
+	getInvoiceByIDScript    = "sql/invoices/get_invoice_by_id.sql"
+	getInvoiceLinesScript   = "sql/invoices/get_invoice_lines.sql"
+	getAllInvoicesScript    = "sql/invoices/get_all_invoices.sql"
+	countInvoicesScript     = "sql/invoices/count_invoices.sql"
+	nextInvoiceNumberScript = "sql/invoices/next_invoice_number.sql"
+	addInvoiceScript        = "sql/invoices/add_invoice.sql"
+	addInvoiceLineScript    = "sql/invoices/add_invoice_line.sql"
+)
+
+// GetInvoiceByID returns a single invoice under the specified ID along with its lines.
+func (repo *InvoiceRepository) GetInvoiceByID(ctx context.Context, id int64) (*Invoice, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getInvoiceByIDScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	row := stmt.QueryRowContext(ctx, id, scopeArg(ctx))
+	invoice := new(Invoice)
+
+	if err = scanInvoice(row, invoice); err != nil {
+		return nil, translateError(err)
+	}
+
+	stmt, err = repo.stmts.stmt(ctx, repo.db, getInvoiceLinesScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.QueryContext(ctx, id)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	invoice.Lines = make([]*InvoiceLine, 0)
+
+	for rows.Next() {
+		line := new(InvoiceLine)
+		err = rows.Scan(&line.Position, &line.ServiceID, &line.Title, &line.Quantity,
+			&line.UnitPrice, &line.Discount, &line.TaxRate, &line.NetAmount, &line.TaxAmount, &line.Notes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		invoice.Lines = append(invoice.Lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return invoice, nil
+}
+
+// invoiceColumns contains the columns invoices can be sorted by.
+var invoiceColumns = map[string]string{
+	"id":          "i.id",
+	"number":      "i.number",
+	"order_id":    "i.order_id",
+	"customer_id": "i.customer_id",
+	"issue_date":  "i.issue_date",
+	"due_date":    "i.due_date",
+	"total":       "i.total",
+}
+
+// GetAllInvoices returns a single page of invoices satisfying the filter without their lines
+// and the total number of such invoices in the database.
+func (repo *InvoiceRepository) GetAllInvoices(ctx context.Context, filter *InvoiceFilter, params *ListParams) ([]*Invoice, int64, error) {
+	script := repo.stmts.script(getAllInvoicesScript)
+	countScript := repo.stmts.script(countInvoicesScript)
+
+	query := new(listQuery)
+
+	if id, ok := CustomerScope(ctx); ok {
+		query.where("i.customer_id = ?", id)
+	}
+
+	if filter.CustomerID != 0 {
+		query.where("i.customer_id = ?", filter.CustomerID)
+	}
+
+	if filter.OrderID != 0 {
+		query.where("i.order_id = ?", filter.OrderID)
+	}
+
+	if filter.DateFrom != nil {
+		query.where("i.issue_date >= ?", *filter.DateFrom)
+	}
+
+	if filter.DateTo != nil {
+		query.where("i.issue_date <= ?", *filter.DateTo)
+	}
+
+	statement, args, err := query.list(script, "i.id", invoiceColumns, params)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := repo.stmts.query(ctx, repo.db, getAllInvoicesScript, statement, args...)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	defer rows.Close()
+
+	invoices := make([]*Invoice, 0)
+
+	for rows.Next() {
+		invoice := new(Invoice)
+
+		if err = scanInvoice(rows, invoice); err != nil {
+			return nil, 0, err
+		}
+
+		invoices = append(invoices, invoice)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	var total int64
+	statement, args = query.count(countScript)
+	err = repo.stmts.queryRow(ctx, repo.db, countInvoicesScript, statement, args...).Scan(&total)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return invoices, total, nil
+}
+
+// IssueInvoice issues the invoice for the order with the issuer, the dates and the payment terms
+// of the invoice. The customer details and the line items of the order are copied to the invoice,
+// and the invoice is filled with them along with its ID, number and totals. It returns
+// a *StatusError if the order is a draft or cancelled, and a *ConstraintError if the order
+// is already invoiced or its services are in several currencies.
+func (repo *InvoiceRepository) IssueInvoice(ctx context.Context, invoice *Invoice) error {
+	orders := &OrderRepository{repo.db, repo.stmts}
+
+	// The order is locked, so its line items can't change while they are copied.
+	return orders.changeOrder(ctx, invoice.OrderID, checkInvoiceable, func(tx queryer, status string) error {
+		txOrders := &OrderRepository{tx, repo.stmts}
+		order, err := txOrders.GetOrderByID(ctx, invoice.OrderID)
+
+		if err != nil {
+			return err
+		}
+
+		items, err := txOrders.GetAllLineItems(ctx, invoice.OrderID)
+
+		if err != nil {
+			return err
+		}
+
+		totals := ComputeTotals(items)
+
+		if len(items) == 0 || totals == nil {
+			message := "the services of the order are in several currencies"
+
+			if len(items) == 0 {
+				message = "the order has no services"
+			}
+
+			return &ConstraintError{Err: ErrCheckViolation, Field: "services", Message: message}
+		}
+
+		customer, err := (&CustomerRepository{tx, repo.stmts}).GetCustomerByID(ctx, order.CustomerID)
+
+		if err != nil {
+			return err
+		}
+
+		invoice.CustomerID = customer.ID
+		// CHAR columns are read from the database padded with spaces.
+		invoice.Customer = Party{customer.Name, customer.Address, strings.TrimRight(customer.TaxID, " ")}
+		invoice.Totals = *totals
+		invoice.Lines = make([]*InvoiceLine, 0, len(items))
+
+		for i, item := range items {
+			invoice.Lines = append(invoice.Lines, &InvoiceLine{
+				Position:  int64(i + 1),
+				ServiceID: item.ServiceID,
+				Title:     item.Title,
+				Quantity:  item.Quantity,
+				UnitPrice: item.UnitPrice,
+				Discount:  item.Discount,
+				TaxRate:   item.TaxRate,
+				NetAmount: item.NetAmount(),
+				TaxAmount: item.TaxAmount(),
+				Notes:     item.Notes,
+			})
+		}
+
+		return (&InvoiceRepository{tx, repo.stmts}).addInvoice(ctx, invoice)
+	})
+}
+
+// addInvoice stores the invoice along with its lines under the next number.
+// The counter of the numbers stays locked until the end of the transaction.
+func (repo *InvoiceRepository) addInvoice(ctx context.Context, invoice *Invoice) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, nextInvoiceNumberScript)
+
+	if err != nil {
+		return err
+	}
+
+	err = stmt.QueryRowContext(ctx).Scan(&invoice.Number)
+
+	if err != nil {
+		return translateError(err)
+	}
+
+	stmt, err = repo.stmts.stmt(ctx, repo.db, addInvoiceScript)
+
+	if err != nil {
+		return err
+	}
+
+	row := stmt.QueryRowContext(ctx, invoice.Number, invoice.OrderID, invoice.CustomerID,
+		invoice.Customer.Name, invoice.Customer.Address, invoice.Customer.TaxID,
+		invoice.Issuer.Name, invoice.Issuer.Address, invoice.Issuer.TaxID,
+		invoice.IssueDate, invoice.DueDate, invoice.PaymentTerms,
+		invoice.Currency, invoice.Subtotal, invoice.Tax, invoice.Total)
+
+	if err = row.Scan(&invoice.ID); err != nil {
+		return translateError(err)
+	}
+
+	stmt, err = repo.stmts.stmt(ctx, repo.db, addInvoiceLineScript)
+
+	if err != nil {
+		return err
+	}
+
+	for _, line := range invoice.Lines {
+		_, err = stmt.ExecContext(ctx, invoice.ID, line.Position, line.ServiceID, line.Title,
+			line.Quantity, line.UnitPrice, line.Discount, line.TaxRate,
+			line.NetAmount, line.TaxAmount, line.Notes)
+
+		if err != nil {
+			return translateError(err)
+		}
+	}
+
+	return nil
+}
+
+// scanInvoice reads the invoice without its lines from the row of any of the invoice scripts.
+func scanInvoice(row interface {
+	Scan(dest ...interface{}) error
+}, invoice *Invoice) error {
+	return row.Scan(&invoice.ID, &invoice.Number, &invoice.OrderID, &invoice.CustomerID,
+		&invoice.Customer.Name, &invoice.Customer.Address, &invoice.Customer.TaxID,
+		&invoice.Issuer.Name, &invoice.Issuer.Address, &invoice.Issuer.TaxID,
+		&invoice.IssueDate, &invoice.DueDate, &invoice.PaymentTerms,
+		&invoice.Currency, &invoice.Subtotal, &invoice.Tax, &invoice.Total)
+}
+
+// NewInvoiceRepo creates a new repository for invoices.
+func NewInvoiceRepo(db *sql.DB, stmts *Statements) *InvoiceRepository {
+	return &InvoiceRepository{db, stmts}
+}
@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSortField is returned when the collection is requested
+	// to be sorted by a field it doesn't have.
+	ErrInvalidSortField = errors.New("invalid sort field")
+	// ErrInvalidCursor is returned when the keyset cursor is used
+	// with the sorting it can't be applied to.
+	ErrInvalidCursor = errors.New("keyset cursor can be used only with sorting by id")
+)
+
+// ListParams contains pagination and sorting parameters for collection queries.
+type ListParams struct {
+	Limit  int
+	Offset int
+	// After is the ID of the last entry of the previous page.
+	// If not zero, the entries are selected by the keyset instead of the offset.
+	After int64
+	Sort  []SortField
+}
+
+// SortField is a single sorting criterion.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Keyset returns true if the entries are sorted by ID only,
+// so the keyset cursor can be applied to them.
+func (params *ListParams) Keyset() bool {
+	return len(params.Sort) == 0 ||
+		len(params.Sort) == 1 && params.Sort[0].Field == "id"
+}
+
+// CustomerFilter contains criteria to filter customers by.
+type CustomerFilter struct {
+	Name        string
+	Address     string
+	TaxID       string
+	Email       string
+	PhoneNumber string
+}
+
+// ServiceFilter contains criteria to filter services by.
+type ServiceFilter struct {
+	Title    string
+	Category string
+	Currency string
+	PriceMin *Money
+	PriceMax *Money
+}
+
+// OrderFilter contains criteria to filter orders by.
+type OrderFilter struct {
+	CustomerID int64
+	DateFrom   *time.Time
+	DateTo     *time.Time
+	Status     string
+}
+
+// InvoiceFilter contains criteria to filter invoices by.
+type InvoiceFilter struct {
+	CustomerID int64
+	OrderID    int64
+	DateFrom   *time.Time
+	DateTo     *time.Time
+}
+
+// TaxRateFilter contains criteria to filter tax rates by.
+type TaxRateFilter struct {
+	Category     string
+	Jurisdiction string
+}
+
+// ExchangeRateFilter contains criteria to filter exchange rates by.
+type ExchangeRateFilter struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	DateFrom      *time.Time
+	DateTo        *time.Time
+}
+
+// listQuery builds a query for a collection
+// out of the base script and the filtering conditions.
+type listQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// where adds a condition with a single "?" placeholder for the argument.
+func (query *listQuery) where(condition string, arg interface{}) {
+	query.args = append(query.args, arg)
+	placeholder := fmt.Sprintf("$%d", len(query.args))
+	query.conditions = append(query.conditions,
+		strings.Replace(condition, "?", placeholder, 1))
+}
+
+// count returns the script to count all the entries satisfying the conditions.
+func (query *listQuery) count(script string) (string, []interface{}) {
+	return trimScript(script) + query.whereClause(query.conditions), query.args
+}
+
+// list returns the script to select a single page of the entries satisfying the conditions.
+// The columns map contains the columns the entries can be sorted by.
+func (query *listQuery) list(script string, idColumn string,
+	columns map[string]string, params *ListParams) (string, []interface{}, error) {
+	if params.After != 0 && !params.Keyset() {
+		return "", nil, ErrInvalidCursor
+	}
+
+	conditions := query.conditions
+	args := query.args
+
+	if params.After != 0 {
+		op := ">"
+
+		if len(params.Sort) > 0 && params.Sort[0].Desc {
+			op = "<"
+		}
+
+		args = append(args, params.After)
+		conditions = append(conditions, fmt.Sprintf("%s %s $%d", idColumn, op, len(args)))
+	}
+
+	order := make([]string, 0, len(params.Sort)+1)
+	sortedByID := false
+
+	for _, field := range params.Sort {
+		column, ok := columns[field.Field]
+
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidSortField, field.Field)
+		}
+
+		if column == idColumn {
+			sortedByID = true
+		}
+
+		if field.Desc {
+			column += " DESC"
+		}
+
+		order = append(order, column)
+	}
+
+	// Sorting by ID makes the order of the pages stable.
+	if !sortedByID {
+		order = append(order, idColumn)
+	}
+
+	statement := trimScript(script) + query.whereClause(conditions) +
+		"\nORDER BY " + strings.Join(order, ", ")
+
+	if params.Limit > 0 {
+		args = append(args, params.Limit)
+		statement += fmt.Sprintf("\nLIMIT $%d", len(args))
+	}
+
+	if params.Offset > 0 {
+		args = append(args, params.Offset)
+		statement += fmt.Sprintf("\nOFFSET $%d", len(args))
+	}
+
+	return statement, args, nil
+}
+
+func (query *listQuery) whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return "\nWHERE " + strings.Join(conditions, " AND ")
+}
+
+// trimScript removes the trailing semicolon from the script
+// so it can be extended with additional clauses.
+func trimScript(script string) string {
+	return strings.TrimSuffix(strings.TrimSpace(script), ";")
+}
+
+// likePattern returns the pattern for the LIKE operator
+// matching any string containing the specified substring.
+func likePattern(substring string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + escaper.Replace(substring) + "%"
+}
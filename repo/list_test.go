@@ -0,0 +1,178 @@
+package repo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testColumns are the sortable columns of the collection in the tests.
+var testColumns = map[string]string{
+	"id":   "c.id",
+	"name": "c.name",
+	"date": "c.date",
+}
+
+const testScript = "SELECT c.id, c.name FROM customers c;\n"
+
+func TestListQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions [][2]interface{}
+		params     ListParams
+		statement  string
+		args       []interface{}
+		err        error
+	}{
+		{
+			name:      "no parameters",
+			statement: "SELECT c.id, c.name FROM customers c\nORDER BY c.id",
+		},
+		{
+			name:       "conditions and pages",
+			conditions: [][2]interface{}{{"c.name ILIKE ?", "%a%"}, {"c.date >= ?", "2024-01-01"}},
+			params:     ListParams{Limit: 10, Offset: 20},
+			statement: "SELECT c.id, c.name FROM customers c\nWHERE c.name ILIKE $1 AND c.date >= $2" +
+				"\nORDER BY c.id\nLIMIT $3\nOFFSET $4",
+			args: []interface{}{"%a%", "2024-01-01", 10, 20},
+		},
+		{
+			name:      "sorted",
+			params:    ListParams{Sort: []SortField{{"name", true}, {"date", false}}},
+			statement: "SELECT c.id, c.name FROM customers c\nORDER BY c.name DESC, c.date, c.id",
+		},
+		{
+			name:      "sorted by id",
+			params:    ListParams{Sort: []SortField{{"id", true}}},
+			statement: "SELECT c.id, c.name FROM customers c\nORDER BY c.id DESC",
+		},
+		{
+			name:      "sorted by id first",
+			params:    ListParams{Sort: []SortField{{"id", false}, {"name", false}}},
+			statement: "SELECT c.id, c.name FROM customers c\nORDER BY c.id, c.name",
+		},
+		{
+			name:      "keyset",
+			params:    ListParams{After: 5, Limit: 10},
+			statement: "SELECT c.id, c.name FROM customers c\nWHERE c.id > $1\nORDER BY c.id\nLIMIT $2",
+			args:      []interface{}{int64(5), 10},
+		},
+		{
+			name:      "descending keyset",
+			params:    ListParams{After: 5, Sort: []SortField{{"id", true}}},
+			statement: "SELECT c.id, c.name FROM customers c\nWHERE c.id < $1\nORDER BY c.id DESC",
+			args:      []interface{}{int64(5)},
+		},
+		{
+			name:       "keyset with conditions",
+			conditions: [][2]interface{}{{"c.name = ?", "a"}},
+			params:     ListParams{After: 5},
+			statement:  "SELECT c.id, c.name FROM customers c\nWHERE c.name = $1 AND c.id > $2\nORDER BY c.id",
+			args:       []interface{}{"a", int64(5)},
+		},
+		{
+			name:   "keyset sorted by another field",
+			params: ListParams{After: 5, Sort: []SortField{{"name", false}}},
+			err:    ErrInvalidCursor,
+		},
+		{
+			name:   "unknown sort field",
+			params: ListParams{Sort: []SortField{{"password", false}}},
+			err:    ErrInvalidSortField,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := new(listQuery)
+
+			for _, condition := range test.conditions {
+				query.where(condition[0].(string), condition[1])
+			}
+
+			statement, args, err := query.list(testScript, "c.id", testColumns, &test.params)
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if statement != test.statement {
+				t.Errorf("statement = %q, want %q", statement, test.statement)
+			}
+
+			if len(args) != 0 || len(test.args) != 0 {
+				if !reflect.DeepEqual(args, test.args) {
+					t.Errorf("args = %#v, want %#v", args, test.args)
+				}
+			}
+		})
+	}
+}
+
+func TestListQueryCount(t *testing.T) {
+	query := new(listQuery)
+	query.where("c.name = ?", "a")
+
+	// The pages and the cursor of the list don't change the count.
+	if _, _, err := query.list(testScript, "c.id", testColumns, &ListParams{After: 5, Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	statement, args := query.count("SELECT COUNT(*) FROM customers c;")
+
+	if want := "SELECT COUNT(*) FROM customers c\nWHERE c.name = $1"; statement != want {
+		t.Errorf("statement = %q, want %q", statement, want)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{"a"}) {
+		t.Errorf("args = %#v, want %#v", args, []interface{}{"a"})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	tests := []struct {
+		sort []SortField
+		want bool
+	}{
+		{nil, true},
+		{[]SortField{{"id", false}}, true},
+		{[]SortField{{"id", true}}, true},
+		{[]SortField{{"name", false}}, false},
+		{[]SortField{{"id", false}, {"name", false}}, false},
+	}
+
+	for _, test := range tests {
+		params := &ListParams{Sort: test.sort}
+
+		if got := params.Keyset(); got != test.want {
+			t.Errorf("Keyset() with %v = %v, want %v", test.sort, got, test.want)
+		}
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	tests := []struct {
+		substring string
+		want      string
+	}{
+		{"", "%%"},
+		{"abc", "%abc%"},
+		{"50%", `%50\%%`},
+		{"a_b", `%a\_b%`},
+		{`c:\dir`, `%c:\\dir%`},
+	}
+
+	for _, test := range tests {
+		if got := likePattern(test.substring); got != test.want {
+			t.Errorf("likePattern(%q) = %q, want %q", test.substring, got, test.want)
+		}
+	}
+}
@@ -5,26 +5,143 @@ import (
 )
 
 // Customer represents a single customer of the company.
+// The jurisdiction selects the tax rates applied to the orders of the customer.
 type Customer struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Address     string `json:"address"`
-	TaxID       string `json:"tax_id"`
-	Email       string `json:"email"`
-	PhoneNumber string `json:"phone_number"`
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Address      string `json:"address"`
+	TaxID        string `json:"tax_id"`
+	Email        string `json:"email"`
+	PhoneNumber  string `json:"phone_number"`
+	Jurisdiction string `json:"jurisdiction"`
 }
 
 // Service represents a single service provided by the company.
+// The category selects the tax rates applied to the service.
+// The price is in the currency with the ISO 4217 code.
 type Service struct {
-	ID          int64   `json:"id"`
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Price       Money  `json:"price"`
+	Currency    string `json:"currency"`
 }
 
 // Order represents a single order made by some of the company's customers.
+// The totals are computed from the line items and are present only
+// when the order is read by its ID or created along with the items.
+// They are omitted if the items are in several currencies
+// unless the totals are converted to a single one. The status is changed
+// only by the transitions, so the one in the request body is ignored.
 type Order struct {
 	ID         int64     `json:"id"`
 	CustomerID int64     `json:"customer_id"`
 	Date       time.Time `json:"date"`
+	Status     string    `json:"status"`
+	*Totals
+}
+
+// StatusChange records who moved the order from one status to another and when.
+// The principal is empty if the authentication is disabled.
+type StatusChange struct {
+	ID         int64     `json:"id"`
+	OrderID    int64     `json:"order_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  string    `json:"changed_by"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// LineItem is a service included in the order in some quantity. The unit price, its currency
+// and the tax rate are the ones in effect at the time the service is added, so the later
+// changes don't affect the order. The discount is a percentage of the line amount.
+type LineItem struct {
+	ID        int64   `json:"id"`
+	OrderID   int64   `json:"order_id"`
+	ServiceID int64   `json:"service_id"`
+	Title     string  `json:"title"`
+	Quantity  int64   `json:"quantity"`
+	UnitPrice Money   `json:"unit_price"`
+	Currency  string  `json:"currency"`
+	Discount  Percent `json:"discount"`
+	TaxRate   Percent `json:"tax_rate"`
+	Notes     string  `json:"notes"`
+}
+
+// OrderWithServices is an order along with the line items included in it.
+type OrderWithServices struct {
+	Order
+	Services []*LineItem `json:"services"`
+}
+
+// TaxRate is the rate of the tax on the services of the category sold to the customers
+// of the jurisdiction. The empty category or jurisdiction matches any. The most specific
+// rate applies: the one matching both, then the category, then the jurisdiction.
+type TaxRate struct {
+	ID           int64   `json:"id"`
+	Category     string  `json:"category"`
+	Jurisdiction string  `json:"jurisdiction"`
+	Rate         Percent `json:"rate"`
+}
+
+// ExchangeRate is the price of a unit of the base currency in the quote currency
+// effective from the date until the next rate of the same currencies.
+type ExchangeRate struct {
+	ID            int64     `json:"id"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          Rate      `json:"rate"`
+	EffectiveDate time.Time `json:"effective_date"`
+}
+
+// Party is the issuer or the recipient of the invoice.
+type Party struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	TaxID   string `json:"tax_id"`
+}
+
+// Invoice is the bill for the order. The parties, the lines and the totals
+// are copied when the invoice is issued, and the invoice never changes afterwards.
+// The numbers of the invoices are sequential without gaps.
+type Invoice struct {
+	ID           int64     `json:"id"`
+	Number       int64     `json:"number"`
+	OrderID      int64     `json:"order_id"`
+	CustomerID   int64     `json:"customer_id"`
+	Customer     Party     `json:"customer"`
+	Issuer       Party     `json:"issuer"`
+	IssueDate    time.Time `json:"issue_date"`
+	DueDate      time.Time `json:"due_date"`
+	PaymentTerms string    `json:"payment_terms"`
+	Totals
+	// Lines are omitted when the invoices are listed.
+	Lines []*InvoiceLine `json:"lines,omitempty"`
+}
+
+// InvoiceLine is a line item of the order copied to the invoice along with its amounts.
+type InvoiceLine struct {
+	Position  int64   `json:"position"`
+	ServiceID int64   `json:"service_id"`
+	Title     string  `json:"title"`
+	Quantity  int64   `json:"quantity"`
+	UnitPrice Money   `json:"unit_price"`
+	Discount  Percent `json:"discount"`
+	TaxRate   Percent `json:"tax_rate"`
+	NetAmount Money   `json:"net_amount"`
+	TaxAmount Money   `json:"tax_amount"`
+	Notes     string  `json:"notes"`
+}
+
+// APIKey is a key the clients authenticate with. Only the hash of the key is stored.
+// The key limited to a customer gives access only to the data of that customer.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"-"`
+	Roles      []string   `json:"roles"`
+	CustomerID *int64     `json:"customer_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
 }
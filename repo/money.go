@@ -0,0 +1,302 @@
+package repo
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Money is an amount of money in cents. It's stored in the DECIMAL columns
+// and marshaled to JSON as a number with 2 decimal places, so the amounts
+// are never rounded by the floating point arithmetic.
+type Money int64
+
+// Percent is a percentage in thousandths of a percent, e.g. 20% is 20000.
+// It's stored in the DECIMAL columns and marshaled to JSON as a number.
+type Percent int64
+
+// Rate is an exchange rate in hundred-millionths.
+// It's stored in the DECIMAL columns and marshaled to JSON as a number.
+type Rate int64
+
+// Number of the decimal places of the money, the percentages and the exchange rates.
+const (
+	moneyScale   = 2
+	percentScale = 3
+	rateScale    = 8
+)
+
+// maxDecimalDigits keeps the decimals within the range of int64.
+const maxDecimalDigits = 18
+
+var errInvalidDecimal = errors.New("must be a decimal number")
+
+// ParseMoney parses the amount written as a decimal number with at most 2 decimal places.
+func ParseMoney(value string) (Money, error) {
+	cents, err := parseDecimal(value, moneyScale)
+	return Money(cents), err
+}
+
+// Times returns the amount multiplied by the quantity.
+func (m Money) Times(quantity int64) Money {
+	return m * Money(quantity)
+}
+
+// Percent returns the percentage of the amount rounded to a cent half away from zero
+// the same way ROUND rounds the numeric values in PostgreSQL.
+func (m Money) Percent(p Percent) Money {
+	product := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(p)))
+	divisor := big.NewInt(100 * pow10(percentScale))
+	quotient, remainder := new(big.Int).QuoRem(product, divisor, new(big.Int))
+
+	// The remainder has the sign of the product.
+	remainder.Abs(remainder).Lsh(remainder, 1)
+
+	if remainder.Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(product.Sign())))
+	}
+
+	return Money(quotient.Int64())
+}
+
+func (m Money) String() string {
+	return formatDecimal(int64(m), moneyScale)
+}
+
+// MarshalJSON writes the amount as a JSON number.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads the amount from a JSON number or a string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	cents, err := unmarshalDecimal(data, moneyScale)
+
+	if err != nil {
+		return fmt.Errorf("incorrect amount of money: %w", err)
+	}
+
+	*m = Money(cents)
+
+	return nil
+}
+
+// Scan reads the amount from a DECIMAL column.
+func (m *Money) Scan(src interface{}) error {
+	cents, err := scanDecimal(src, moneyScale)
+
+	if err != nil {
+		return fmt.Errorf("incorrect amount of money: %w", err)
+	}
+
+	*m = Money(cents)
+
+	return nil
+}
+
+// Value writes the amount to a DECIMAL column.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// ParsePercent parses the percentage written as a decimal number with at most 3 decimal places.
+func ParsePercent(value string) (Percent, error) {
+	thousandths, err := parseDecimal(value, percentScale)
+	return Percent(thousandths), err
+}
+
+func (p Percent) String() string {
+	return formatDecimal(int64(p), percentScale)
+}
+
+// MarshalJSON writes the percentage as a JSON number.
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalJSON reads the percentage from a JSON number or a string.
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	thousandths, err := unmarshalDecimal(data, percentScale)
+
+	if err != nil {
+		return fmt.Errorf("incorrect percentage: %w", err)
+	}
+
+	*p = Percent(thousandths)
+
+	return nil
+}
+
+// Scan reads the percentage from a DECIMAL column.
+func (p *Percent) Scan(src interface{}) error {
+	thousandths, err := scanDecimal(src, percentScale)
+
+	if err != nil {
+		return fmt.Errorf("incorrect percentage: %w", err)
+	}
+
+	*p = Percent(thousandths)
+
+	return nil
+}
+
+// Value writes the percentage to a DECIMAL column.
+func (p Percent) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+func (rate Rate) String() string {
+	return formatDecimal(int64(rate), rateScale)
+}
+
+// MarshalJSON writes the exchange rate as a JSON number.
+func (rate Rate) MarshalJSON() ([]byte, error) {
+	return []byte(rate.String()), nil
+}
+
+// UnmarshalJSON reads the exchange rate from a JSON number or a string.
+func (rate *Rate) UnmarshalJSON(data []byte) error {
+	units, err := unmarshalDecimal(data, rateScale)
+
+	if err != nil {
+		return fmt.Errorf("incorrect exchange rate: %w", err)
+	}
+
+	*rate = Rate(units)
+
+	return nil
+}
+
+// Scan reads the exchange rate from a DECIMAL column.
+func (rate *Rate) Scan(src interface{}) error {
+	units, err := scanDecimal(src, rateScale)
+
+	if err != nil {
+		return fmt.Errorf("incorrect exchange rate: %w", err)
+	}
+
+	*rate = Rate(units)
+
+	return nil
+}
+
+// Value writes the exchange rate to a DECIMAL column.
+func (rate Rate) Value() (driver.Value, error) {
+	return rate.String(), nil
+}
+
+// parseDecimal converts the decimal number to an integer number of its smallest units
+// defined by the scale. It fails if the number has more significant decimal places.
+func parseDecimal(value string, scale int) (int64, error) {
+	digits := value
+	negative := false
+
+	if strings.HasPrefix(digits, "-") {
+		digits, negative = digits[1:], true
+	}
+
+	whole, fraction := digits, ""
+
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, fraction = digits[:i], digits[i+1:]
+
+		if fraction == "" {
+			return 0, errInvalidDecimal
+		}
+	}
+
+	// The trailing zeros don't make the number more precise.
+	fraction = strings.TrimRight(fraction, "0")
+
+	if len(fraction) > scale {
+		return 0, fmt.Errorf("must have at most %d decimal places", scale)
+	}
+
+	fraction += strings.Repeat("0", scale-len(fraction))
+	whole = strings.TrimLeft(whole, "0")
+
+	if len(whole)+scale > maxDecimalDigits {
+		return 0, errors.New("is too large")
+	}
+
+	if digits == "" || !isDigits(whole) || !isDigits(fraction) {
+		return 0, errInvalidDecimal
+	}
+
+	var number int64
+
+	for _, r := range whole + fraction {
+		number = number*10 + int64(r-'0')
+	}
+
+	if negative {
+		number = -number
+	}
+
+	return number, nil
+}
+
+// formatDecimal writes the integer number of the smallest units as a decimal number.
+func formatDecimal(number int64, scale int) string {
+	sign := ""
+
+	if number < 0 {
+		sign, number = "-", -number
+	}
+
+	unit := pow10(scale)
+
+	return fmt.Sprintf("%s%d.%0*d", sign, number/unit, scale, number%unit)
+}
+
+func unmarshalDecimal(data []byte, scale int) (int64, error) {
+	value := string(data)
+
+	// The null is read as zero.
+	if value == "null" {
+		return 0, nil
+	}
+
+	if strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) && len(value) > 1 {
+		value = value[1 : len(value)-1]
+	}
+
+	return parseDecimal(value, scale)
+}
+
+func scanDecimal(src interface{}, scale int) (int64, error) {
+	switch value := src.(type) {
+	case []byte:
+		return parseDecimal(string(value), scale)
+
+	case string:
+		return parseDecimal(value, scale)
+
+	case int64:
+		return value * pow10(scale), nil
+	}
+
+	return 0, fmt.Errorf("unsupported type %T", src)
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func pow10(exponent int) int64 {
+	result := int64(1)
+
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+
+	return result
+}
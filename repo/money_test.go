@@ -0,0 +1,199 @@
+package repo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Money
+		err   bool
+	}{
+		{"12.34", 1234, false},
+		{"12", 1200, false},
+		{"12.3", 1230, false},
+		{"12.340", 1234, false},
+		{"0012.50", 1250, false},
+		{"-0.05", -5, false},
+		{".5", 50, false},
+		{"0", 0, false},
+		{"9999999999999999.99", 999999999999999999, false},
+		{"0.001", 0, true},
+		{"12.", 0, true},
+		{"", 0, true},
+		{"-", 0, true},
+		{"+5", 0, true},
+		{"1e3", 0, true},
+		{"1.a", 0, true},
+		{"1 000", 0, true},
+		{"99999999999999999", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMoney(test.value)
+
+		if (err != nil) != test.err {
+			t.Errorf("ParseMoney(%q) error = %v, want error %v", test.value, err, test.err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", test.value, got, test.want)
+		}
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Percent
+		err   bool
+	}{
+		{"20", 20000, false},
+		{"12.5", 12500, false},
+		{"0.125", 125, false},
+		{"100.000", 100000, false},
+		{"0.0001", 0, true},
+		{"twenty", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParsePercent(test.value)
+
+		if (err != nil) != test.err {
+			t.Errorf("ParsePercent(%q) error = %v, want error %v", test.value, err, test.err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("ParsePercent(%q) = %d, want %d", test.value, got, test.want)
+		}
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		amount  Money
+		percent Percent
+		want    Money
+	}{
+		{1000, 20000, 200},
+		{0, 20000, 0},
+		{1000, 0, 0},
+		// The halves are rounded away from zero.
+		{1, 50000, 1},
+		{-1, 50000, -1},
+		{1, 49999, 0},
+		{-1, 49999, 0},
+		{333, 33333, 111},
+		{999, 12500, 125},
+		{-999, 12500, -125},
+		// The product exceeds int64 before it's divided.
+		{900000000000000000, 100000, 900000000000000000},
+	}
+
+	for _, test := range tests {
+		if got := test.amount.Percent(test.percent); got != test.want {
+			t.Errorf("Money(%d).Percent(%d) = %d, want %d", test.amount, test.percent, got, test.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		amount Money
+		want   string
+	}{
+		{1234, "12.34"},
+		{100, "1.00"},
+		{5, "0.05"},
+		{0, "0.00"},
+		{-5, "-0.05"},
+		{-1234, "-12.34"},
+	}
+
+	for _, test := range tests {
+		if got := test.amount.String(); got != test.want {
+			t.Errorf("Money(%d).String() = %q, want %q", test.amount, got, test.want)
+		}
+	}
+
+	if got := Percent(20000).String(); got != "20.000" {
+		t.Errorf("Percent(20000).String() = %q, want %q", got, "20.000")
+	}
+
+	if got := Rate(150000000).String(); got != "1.50000000" {
+		t.Errorf("Rate(150000000).String() = %q, want %q", got, "1.50000000")
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want Money
+		err  bool
+	}{
+		{`12.34`, 1234, false},
+		{`"12.34"`, 1234, false},
+		{`7`, 700, false},
+		{`null`, 0, false},
+		{`12.345`, 0, true},
+		{`"abc"`, 0, true},
+		{`true`, 0, true},
+	}
+
+	for _, test := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(test.data), &got)
+
+		if (err != nil) != test.err {
+			t.Errorf("unmarshaling %s: error = %v, want error %v", test.data, err, test.err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("unmarshaling %s = %d, want %d", test.data, got, test.want)
+		}
+	}
+
+	data, err := json.Marshal(struct{ Price Money }{-1250})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"Price":-12.50}` {
+		t.Errorf("marshaling = %s, want %s", data, `{"Price":-12.50}`)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Money
+		err  bool
+	}{
+		{[]byte("12.30"), 1230, false},
+		{"5", 500, false},
+		{int64(7), 700, false},
+		{[]byte("1.005"), 0, true},
+		{12.3, 0, true},
+		{nil, 0, true},
+	}
+
+	for _, test := range tests {
+		var got Money
+		err := got.Scan(test.src)
+
+		if (err != nil) != test.err {
+			t.Errorf("Scan(%#v) error = %v, want error %v", test.src, err, test.err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("Scan(%#v) = %d, want %d", test.src, got, test.want)
+		}
+	}
+}
@@ -1,180 +1,564 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
-	"restApp/assets"
+	"errors"
+	"fmt"
 )
 
 // OrderRepository represents a data repository and implements CRUD methods for orders.
 type OrderRepository struct {
-	db *sql.DB
+	db    queryer
+	stmts *Statements
 }
 
-// GetOrderByID returns a single order under the specified ID.
-func (repo *OrderRepository) GetOrderByID(id int64) (*Order, error) {
-	script, err := assets.Asset("sql/orders/get_order_by_id.sql")
+// Scripts used by the order repository.
+const (
+	getAllOrdersScript     = "sql/orders/get_all_orders.sql"
+	countOrdersScript      = "sql/orders/count_orders.sql"
+	getOrderByIDScript     = "sql/orders/get_order_by_id.sql"
+	getOrderTotalsScript   = "sql/orders/get_order_totals.sql"
+	addOrderScript         = "sql/orders/add_order.sql"
+	updateOrderScript      = "sql/orders/update_order.sql"
+	deleteOrderScript      = "sql/orders/delete_order.sql"
+	getLineItemByIDScript  = "sql/orders/get_line_item_by_id.sql"
+	getAllLineItemsScript  = "sql/orders/get_all_line_items.sql"
+	addLineItemScript      = "sql/orders/add_line_item.sql"
+	updateLineItemScript   = "sql/orders/update_line_item.sql"
+	deleteLineItemScript   = "sql/orders/delete_line_item.sql"
+	deleteLineItemsScript  = "sql/orders/delete_order_line_items.sql"
+	getOrderStatusScript   = "sql/orders/get_order_status.sql"
+	lockOrderScript        = "sql/orders/lock_order.sql"
+	setOrderStatusScript   = "sql/orders/set_order_status.sql"
+	addStatusChangeScript  = "sql/orders/add_status_change.sql"
+	getStatusHistoryScript = "sql/orders/get_status_history.sql"
+)
+
+// GetOrderByID returns a single order under the specified ID along with its totals
+// if all its line items are in the same currency.
+func (repo *OrderRepository) GetOrderByID(ctx context.Context, id int64) (*Order, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getOrderByIDScript)
 
 	if err != nil {
 		return nil, err
 	}
 
-	row := repo.db.QueryRow(string(script), id)
-	order := new(Order)
-	err = row.Scan(&order.ID, &order.CustomerID, &order.Date)
+	row := stmt.QueryRowContext(ctx, id, scopeArg(ctx))
+	order := &Order{Totals: new(Totals)}
+	var currencies int
+	err = row.Scan(&order.ID, &order.CustomerID, &order.Date, &order.Status, &order.Currency,
+		&currencies, &order.Subtotal, &order.Tax, &order.Total)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
+	}
+
+	if currencies > 1 {
+		order.Totals = nil
 	}
 
 	return order, nil
 }
 
-// GetAllOrders returns a set of all orders from the database.
-func (repo *OrderRepository) GetAllOrders() ([]*Order, error) {
-	script, err := assets.Asset("sql/orders/get_all_orders.sql")
+// GetOrderTotals returns the totals of the order converted to the currency
+// at the exchange rates effective on the date of the order. Every line item
+// is converted and rounded to a cent before the amounts are summed up.
+// It returns ErrNoExchangeRate if any of the items can't be converted.
+func (repo *OrderRepository) GetOrderTotals(ctx context.Context, id int64, currency string) (*Totals, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getOrderTotalsScript)
 
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := repo.db.Query(string(script))
+	row := stmt.QueryRowContext(ctx, id, currency, scopeArg(ctx))
+	totals := &Totals{Currency: currency}
+	var missing string
+	err = row.Scan(&totals.Subtotal, &totals.Tax, &totals.Total, &missing)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
+	}
+
+	if missing != "" {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoExchangeRate, missing, currency)
+	}
+
+	return totals, nil
+}
+
+// orderColumns contains the columns orders can be sorted by.
+var orderColumns = map[string]string{
+	"id":          "o.id",
+	"customer_id": "o.customer_id",
+	"date":        "o.contract_date",
+	"status":      "o.status",
+}
+
+// GetAllOrders returns a single page of orders satisfying the filter
+// and the total number of such orders in the database.
+func (repo *OrderRepository) GetAllOrders(ctx context.Context, filter *OrderFilter, params *ListParams) ([]*Order, int64, error) {
+	script := repo.stmts.script(getAllOrdersScript)
+	countScript := repo.stmts.script(countOrdersScript)
+
+	query := new(listQuery)
+
+	if id, ok := CustomerScope(ctx); ok {
+		query.where("o.customer_id = ?", id)
+	}
+
+	if filter.CustomerID != 0 {
+		query.where("o.customer_id = ?", filter.CustomerID)
+	}
+
+	if filter.DateFrom != nil {
+		query.where("o.contract_date >= ?", *filter.DateFrom)
+	}
+
+	if filter.DateTo != nil {
+		query.where("o.contract_date <= ?", *filter.DateTo)
 	}
 
+	if filter.Status != "" {
+		query.where("o.status = ?", filter.Status)
+	}
+
+	statement, args, err := query.list(script, "o.id", orderColumns, params)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := repo.stmts.query(ctx, repo.db, getAllOrdersScript, statement, args...)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	defer rows.Close()
+
 	orders := make([]*Order, 0)
 
 	for rows.Next() {
 		order := new(Order)
-		err = rows.Scan(&order.ID, &order.CustomerID, &order.Date)
+		err = rows.Scan(&order.ID, &order.CustomerID, &order.Date, &order.Status)
 
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		orders = append(orders, order)
 	}
 
-	return orders, nil
-}
+	if err = rows.Err(); err != nil {
+		return nil, 0, translateError(err)
+	}
 
-// AddOrder adds a new order to the database.
-func (repo *OrderRepository) AddOrder(order *Order) error {
-	script, err := assets.Asset("sql/orders/add_order.sql")
+	var total int64
+	statement, args = query.count(countScript)
+	err = repo.stmts.queryRow(ctx, repo.db, countOrdersScript, statement, args...).Scan(&total)
 
 	if err != nil {
-		return err
+		return nil, 0, translateError(err)
 	}
 
-	_, err = repo.db.Exec(string(script), order.CustomerID, order.Date)
-
-	return err
+	return orders, total, nil
 }
 
-// UpdateOrder updates the order in the database.
-func (repo *OrderRepository) UpdateOrder(order *Order) error {
-	script, err := assets.Asset("sql/orders/update_order.sql")
+// AddOrder adds a new draft order to the database
+// and fills the order with the stored data including its ID and status.
+func (repo *OrderRepository) AddOrder(ctx context.Context, order *Order) error {
+	if id, ok := CustomerScope(ctx); ok && order.CustomerID != id {
+		// The orders of the other customers can't be created as if the customers didn't exist.
+		return &ConstraintError{
+			Err:     ErrForeignKeyViolation,
+			Field:   "customer_id",
+			Message: "the customer doesn't exist",
+		}
+	}
+
+	stmt, err := repo.stmts.stmt(ctx, repo.db, addOrderScript)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.db.Exec(string(script), order.ID, order.Date)
+	row := stmt.QueryRowContext(ctx, order.CustomerID, order.Date)
+	err = row.Scan(&order.ID, &order.CustomerID, &order.Date, &order.Status)
 
-	return err
+	return translateError(err)
 }
 
-// DeleteOrder deletes the order from the database.
-func (repo *OrderRepository) DeleteOrder(id int64) error {
-	script, err := assets.Asset("sql/orders/delete_order.sql")
+// CreateOrder adds a new order along with its line items to the database in a single transaction
+// and fills the order and the items with the stored data including their IDs and the totals.
+// Nothing is stored if any of the services doesn't exist. If the repository is bound to a transaction,
+// the order is created in it.
+func (repo *OrderRepository) CreateOrder(ctx context.Context, order *OrderWithServices) error {
+	return inTx(ctx, repo.db, func(tx queryer) error {
+		txRepo := &OrderRepository{tx, repo.stmts}
+		err := txRepo.AddOrder(ctx, &order.Order)
+
+		if err != nil {
+			return err
+		}
+
+		for i, item := range order.Services {
+			item.OrderID = order.ID
+			err = txRepo.AddLineItem(ctx, item)
+
+			if err != nil {
+				var constraintErr *ConstraintError
+
+				if errors.As(err, &constraintErr) {
+					field := fmt.Sprintf("services[%d]", i)
+
+					if constraintErr.Field != "" {
+						field += "." + constraintErr.Field
+					}
+
+					constraintErr.Field = field
+				}
+
+				return err
+			}
+		}
+
+		order.Totals = ComputeTotals(order.Services)
+
+		return nil
+	})
+}
+
+// UpdateOrder updates the draft order in the database and fills the order with its status.
+func (repo *OrderRepository) UpdateOrder(ctx context.Context, order *Order) error {
+	return repo.changeOrder(ctx, order.ID, checkEditable, func(tx queryer, status string) error {
+		stmt, err := repo.stmts.stmt(ctx, tx, updateOrderScript)
+
+		if err != nil {
+			return err
+		}
+
+		result, err := stmt.ExecContext(ctx, order.ID, order.CustomerID, order.Date, scopeArg(ctx))
+
+		if err != nil {
+			return translateError(err)
+		}
+
+		order.Status = status
+
+		return checkAffected(result)
+	})
+}
+
+// DeleteOrder deletes the draft or cancelled order from the database
+// along with its line items and history.
+func (repo *OrderRepository) DeleteOrder(ctx context.Context, id int64) error {
+	return repo.changeOrder(ctx, id, checkDeletable, func(tx queryer, status string) error {
+		// The line items reference the order, so they go first. The history is deleted
+		// by the database along with the order.
+		stmt, err := repo.stmts.stmt(ctx, tx, deleteLineItemsScript)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx, id, scopeArg(ctx))
+
+		if err != nil {
+			return translateError(err)
+		}
+
+		stmt, err = repo.stmts.stmt(ctx, tx, deleteOrderScript)
+
+		if err != nil {
+			return err
+		}
+
+		result, err := stmt.ExecContext(ctx, id, scopeArg(ctx))
+
+		if err != nil {
+			return translateError(err)
+		}
+
+		return checkAffected(result)
+	})
+}
+
+// TransitionOrder moves the order to the next status by the action and records
+// the change made by the principal in the history of the order. It returns
+// a *StatusError if the action isn't allowed in the current status
+// or the order has no line items to be confirmed.
+func (repo *OrderRepository) TransitionOrder(ctx context.Context, id int64,
+	action string, principal string) (*StatusChange, error) {
+	var next string
+	check := func(status string) error {
+		var err error
+		next, err = nextStatus(status, action)
 
-	if err != nil {
 		return err
 	}
+	change := new(StatusChange)
 
-	_, err = repo.db.Exec(string(script), id)
+	err := repo.changeOrder(ctx, id, check, func(tx queryer, status string) error {
+		txRepo := &OrderRepository{tx, repo.stmts}
 
-	return err
-}
+		if next == StatusConfirmed {
+			items, err := txRepo.GetAllLineItems(ctx, id)
+
+			if err != nil {
+				return err
+			}
+
+			if len(items) == 0 {
+				return &StatusError{
+					Status:  status,
+					Message: "the order has no services and can't be confirmed",
+				}
+			}
+		}
 
-// GetOrderServiceByID returns a single service included in the order by its ID.
-func (repo *OrderRepository) GetOrderServiceByID(orderID int64, serviceID int64) (*Service, error) {
-	script, err := assets.Asset("sql/orders/get_order_service_by_id.sql")
+		stmt, err := repo.stmts.stmt(ctx, tx, setOrderStatusScript)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx, id, next)
+
+		if err != nil {
+			return translateError(err)
+		}
+
+		stmt, err = repo.stmts.stmt(ctx, tx, addStatusChangeScript)
+
+		if err != nil {
+			return err
+		}
+
+		row := stmt.QueryRowContext(ctx, id, status, next, principal)
+		err = scanStatusChange(row, change)
+
+		return translateError(err)
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	row := repo.db.QueryRow(string(script), orderID, serviceID)
-	service := new(Service)
-	err = row.Scan(&service.ID, &service.Title, &service.Description, &service.Price)
+	return change, nil
+}
+
+// GetStatusHistory returns the status changes of the order from the oldest to the newest.
+func (repo *OrderRepository) GetStatusHistory(ctx context.Context, id int64) ([]*StatusChange, error) {
+	// Check if the order exists and is within the customer scope.
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getOrderStatusScript)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return service, nil
-}
+	var status string
+	err = stmt.QueryRowContext(ctx, id, scopeArg(ctx)).Scan(&status)
 
-// GetAllOrderServices returns all the services included in the order.
-func (repo *OrderRepository) GetAllOrderServices(orderID int64) ([]*Service, error) {
-	script, err := assets.Asset("sql/orders/get_all_order_services.sql")
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	stmt, err = repo.stmts.stmt(ctx, repo.db, getStatusHistoryScript)
 
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := repo.db.Query(string(script), orderID)
+	rows, err := stmt.QueryContext(ctx, id)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
+	defer rows.Close()
 
-	services := make([]*Service, 0)
+	changes := make([]*StatusChange, 0)
 
 	for rows.Next() {
-		service := new(Service)
+		change := new(StatusChange)
 
-		err = rows.Scan(&service.ID, &service.Title, &service.Description, &service.Price)
-
-		if err != nil {
+		if err = scanStatusChange(rows, change); err != nil {
 			return nil, err
 		}
 
-		services = append(services, service)
+		changes = append(changes, change)
 	}
 
-	return services, nil
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return changes, nil
+}
+
+// changeOrder locks the order until the end of the transaction and runs the function
+// in it if the check accepts the current status of the order. If the repository
+// is bound to a transaction, the function is run in it.
+func (repo *OrderRepository) changeOrder(ctx context.Context, id int64, check func(status string) error,
+	fn func(tx queryer, status string) error) error {
+	return inTx(ctx, repo.db, func(tx queryer) error {
+		stmt, err := repo.stmts.stmt(ctx, tx, lockOrderScript)
+
+		if err != nil {
+			return err
+		}
+
+		var status string
+		err = stmt.QueryRowContext(ctx, id, scopeArg(ctx)).Scan(&status)
+
+		if err != nil {
+			return translateError(err)
+		}
+
+		if err = check(status); err != nil {
+			return err
+		}
+
+		return fn(tx, status)
+	})
 }
 
-// AddServiceToOrder adds a service to the order.
-func (repo *OrderRepository) AddServiceToOrder(orderID int64, serviceID int64) error {
-	script, err := assets.Asset("sql/orders/add_service_to_order.sql")
+// GetLineItemByID returns a single line item of the order by its ID.
+func (repo *OrderRepository) GetLineItemByID(ctx context.Context, orderID int64, id int64) (*LineItem, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getLineItemByIDScript)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = repo.db.Exec(string(script), orderID, serviceID)
+	row := stmt.QueryRowContext(ctx, orderID, id, scopeArg(ctx))
+	item := new(LineItem)
+	err = scanLineItem(row, item)
 
-	return err
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return item, nil
 }
 
-// DeleteServiceFromOrder deleted the service from the order.
-func (repo *OrderRepository) DeleteServiceFromOrder(orderID int64, serviceID int64) error {
-	script, err := assets.Asset("sql/orders/delete_service_from_order.sql")
+// GetAllLineItems returns all the line items of the order in the order they were added.
+func (repo *OrderRepository) GetAllLineItems(ctx context.Context, orderID int64) ([]*LineItem, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getAllLineItemsScript)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = repo.db.Exec(string(script), orderID, serviceID)
+	rows, err := stmt.QueryContext(ctx, orderID, scopeArg(ctx))
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	items := make([]*LineItem, 0)
+
+	for rows.Next() {
+		item := new(LineItem)
+
+		if err = scanLineItem(rows, item); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return items, nil
+}
+
+// AddLineItem adds the line item to the order taking the current price of the service
+// as the unit price and the tax rate matching the category of the service
+// and the jurisdiction of the customer, and fills the item with the stored data including its ID.
+// It returns ErrNotFound if the order doesn't exist, a *StatusError if the order
+// isn't a draft and a *ConstraintError if the service doesn't exist.
+func (repo *OrderRepository) AddLineItem(ctx context.Context, item *LineItem) error {
+	return repo.changeOrder(ctx, item.OrderID, checkEditable, func(tx queryer, status string) error {
+		stmt, err := repo.stmts.stmt(ctx, tx, addLineItemScript)
+
+		if err != nil {
+			return err
+		}
+
+		row := stmt.QueryRowContext(ctx, item.OrderID, item.ServiceID,
+			item.Quantity, item.Discount, item.Notes, scopeArg(ctx))
+		err = translateError(scanLineItem(row, item))
+
+		if errors.Is(err, ErrNotFound) {
+			return &ConstraintError{
+				Err:     ErrForeignKeyViolation,
+				Field:   "service_id",
+				Message: "the service doesn't exist",
+			}
+		}
+
+		return err
+	})
+}
+
+// UpdateLineItem updates the quantity, the discount and the notes of the line item
+// and fills the item with the stored data. The service, the unit price, its currency
+// and the tax rate don't change. The order must be a draft.
+func (repo *OrderRepository) UpdateLineItem(ctx context.Context, item *LineItem) error {
+	return repo.changeOrder(ctx, item.OrderID, checkEditable, func(tx queryer, status string) error {
+		stmt, err := repo.stmts.stmt(ctx, tx, updateLineItemScript)
+
+		if err != nil {
+			return err
+		}
+
+		row := stmt.QueryRowContext(ctx, item.OrderID, item.ID,
+			item.Quantity, item.Discount, item.Notes, scopeArg(ctx))
+
+		return translateError(scanLineItem(row, item))
+	})
+}
+
+// DeleteLineItem deletes the line item from the draft order.
+func (repo *OrderRepository) DeleteLineItem(ctx context.Context, orderID int64, id int64) error {
+	return repo.changeOrder(ctx, orderID, checkEditable, func(tx queryer, status string) error {
+		stmt, err := repo.stmts.stmt(ctx, tx, deleteLineItemScript)
+
+		if err != nil {
+			return err
+		}
+
+		result, err := stmt.ExecContext(ctx, orderID, id, scopeArg(ctx))
+
+		if err != nil {
+			return translateError(err)
+		}
+
+		return checkAffected(result)
+	})
+}
+
+// scanLineItem reads the line item from the row of any of the line item scripts.
+func scanLineItem(row interface {
+	Scan(dest ...interface{}) error
+}, item *LineItem) error {
+	return row.Scan(&item.ID, &item.OrderID, &item.ServiceID, &item.Title,
+		&item.Quantity, &item.UnitPrice, &item.Currency, &item.Discount, &item.TaxRate, &item.Notes)
+}
 
-	return err
+// scanStatusChange reads the status change from the row of any of the history scripts.
+func scanStatusChange(row interface {
+	Scan(dest ...interface{}) error
+}, change *StatusChange) error {
+	return row.Scan(&change.ID, &change.OrderID, &change.FromStatus,
+		&change.ToStatus, &change.ChangedBy, &change.ChangedAt)
 }
 
-// NewOrderRepository creates a new repository for orders and their services.
-func NewOrderRepository(db *sql.DB) *OrderRepository {
-	return &OrderRepository{db}
+// NewOrderRepository creates a new repository for orders and their line items.
+func NewOrderRepository(db *sql.DB, stmts *Statements) *OrderRepository {
+	return &OrderRepository{db, stmts}
 }
@@ -1,32 +1,86 @@
 package repo
 
+import "context"
+
+// The methods of the repositories return ErrNotFound if the requested entry doesn't exist,
+// *ConstraintError if the operation violates the database schema, ErrUnavailable
+// if the database can't be accessed, ErrTxConflict if the transaction
+// conflicts with a concurrent one and ErrCanceled if the context
+// is canceled or its deadline is exceeded. The methods changing the orders
+// and their line items return *StatusError if the status of the order
+// doesn't allow the change. If the context is limited
+// by WithCustomerScope, the entries of the other customers don't exist for them.
+
 // ICustomerRepository provides CRUD interface for customers.
 type ICustomerRepository interface {
-	GetCustomerByID(id int64) (*Customer, error)
-	GetAllCustomers() ([]*Customer, error)
-	AddCustomer(customer *Customer) error
-	UpdateCustomer(customer *Customer) error
-	DeleteCustomer(id int64) error
+	GetCustomerByID(ctx context.Context, id int64) (*Customer, error)
+	GetAllCustomers(ctx context.Context, filter *CustomerFilter, params *ListParams) ([]*Customer, int64, error)
+	AddCustomer(ctx context.Context, customer *Customer) error
+	UpdateCustomer(ctx context.Context, customer *Customer) error
+	DeleteCustomer(ctx context.Context, id int64) error
 }
 
 // IServiceRepository provides CRUD interface for services.
 type IServiceRepository interface {
-	GetServiceByID(id int64) (*Service, error)
-	GetAllServices() ([]*Service, error)
-	AddService(service *Service) error
-	UpdateService(service *Service) error
-	DeleteService(id int64) error
+	GetServiceByID(ctx context.Context, id int64) (*Service, error)
+	GetAllServices(ctx context.Context, filter *ServiceFilter, params *ListParams) ([]*Service, int64, error)
+	AddService(ctx context.Context, service *Service) error
+	UpdateService(ctx context.Context, service *Service) error
+	DeleteService(ctx context.Context, id int64) error
 }
 
 // IOrderRepository provides CRUD interface for orders.
 type IOrderRepository interface {
-	GetOrderByID(id int64) (*Order, error)
-	GetAllOrders() ([]*Order, error)
-	AddOrder(order *Order) error
-	UpdateOrder(order *Order) error
-	DeleteOrder(id int64) error
-	GetOrderServiceByID(orderID int64, serviceID int64) (*Service, error)
-	GetAllOrderServices(orderID int64) ([]*Service, error)
-	AddServiceToOrder(orderID int64, serviceID int64) error
-	DeleteServiceFromOrder(orderID int64, serviceID int64) error
+	GetOrderByID(ctx context.Context, id int64) (*Order, error)
+	GetOrderTotals(ctx context.Context, id int64, currency string) (*Totals, error)
+	GetAllOrders(ctx context.Context, filter *OrderFilter, params *ListParams) ([]*Order, int64, error)
+	AddOrder(ctx context.Context, order *Order) error
+	CreateOrder(ctx context.Context, order *OrderWithServices) error
+	UpdateOrder(ctx context.Context, order *Order) error
+	DeleteOrder(ctx context.Context, id int64) error
+	GetLineItemByID(ctx context.Context, orderID int64, id int64) (*LineItem, error)
+	GetAllLineItems(ctx context.Context, orderID int64) ([]*LineItem, error)
+	AddLineItem(ctx context.Context, item *LineItem) error
+	UpdateLineItem(ctx context.Context, item *LineItem) error
+	DeleteLineItem(ctx context.Context, orderID int64, id int64) error
+	TransitionOrder(ctx context.Context, id int64, action string, principal string) (*StatusChange, error)
+	GetStatusHistory(ctx context.Context, id int64) ([]*StatusChange, error)
+}
+
+// ITaxRateRepository provides CRUD interface for tax rates.
+type ITaxRateRepository interface {
+	GetTaxRateByID(ctx context.Context, id int64) (*TaxRate, error)
+	GetAllTaxRates(ctx context.Context, filter *TaxRateFilter, params *ListParams) ([]*TaxRate, int64, error)
+	AddTaxRate(ctx context.Context, rate *TaxRate) error
+	UpdateTaxRate(ctx context.Context, rate *TaxRate) error
+	DeleteTaxRate(ctx context.Context, id int64) error
+}
+
+// IExchangeRateRepository provides CRUD interface for exchange rates.
+type IExchangeRateRepository interface {
+	GetExchangeRateByID(ctx context.Context, id int64) (*ExchangeRate, error)
+	GetAllExchangeRates(ctx context.Context, filter *ExchangeRateFilter, params *ListParams) ([]*ExchangeRate, int64, error)
+	AddExchangeRate(ctx context.Context, rate *ExchangeRate) error
+	UpdateExchangeRate(ctx context.Context, rate *ExchangeRate) error
+	DeleteExchangeRate(ctx context.Context, id int64) error
+}
+
+// IInvoiceRepository provides the issuing and the reading of the invoices.
+type IInvoiceRepository interface {
+	GetInvoiceByID(ctx context.Context, id int64) (*Invoice, error)
+	GetAllInvoices(ctx context.Context, filter *InvoiceFilter, params *ListParams) ([]*Invoice, int64, error)
+	IssueInvoice(ctx context.Context, invoice *Invoice) error
+}
+
+// IAPIKeyRepository provides the storage of the API keys.
+type IAPIKeyRepository interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	GetAllAPIKeys(ctx context.Context) ([]*APIKey, error)
+	AddAPIKey(ctx context.Context, key *APIKey) error
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+// IUnitOfWork runs operations spanning multiple repositories in a single transaction.
+type IUnitOfWork interface {
+	WithTx(ctx context.Context, fn func(repos *Repos) error) error
 }
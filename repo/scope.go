@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+type contextKey int
+
+const customerScopeKey contextKey = iota
+
+// WithCustomerScope returns the context limiting the repositories to the data of the customer:
+// the customer itself and its orders. The entries of the other customers
+// are reported as nonexistent.
+func WithCustomerScope(ctx context.Context, customerID int64) context.Context {
+	return context.WithValue(ctx, customerScopeKey, customerID)
+}
+
+// CustomerScope returns the ID of the customer the context limits the repositories to.
+func CustomerScope(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(customerScopeKey).(int64)
+	return id, ok
+}
+
+// scopeArg returns the customer scope as an argument of the scripts
+// which is NULL if the context doesn't limit the data.
+func scopeArg(ctx context.Context) sql.NullInt64 {
+	id, ok := CustomerScope(ctx)
+	return sql.NullInt64{Int64: id, Valid: ok}
+}
@@ -1,105 +1,179 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
-	"restApp/assets"
 )
 
 // ServiceRepository represents a data repository and implements CRUD methods for services.
 type ServiceRepository struct {
-	db *sql.DB
+	db    queryer
+	stmts *Statements
 }
 
+// Scripts used by the service repository.
+const (
+	getAllServicesScript = "sql/services/get_all_services.sql"
+	countServicesScript  = "sql/services/count_services.sql"
+	getServiceByIDScript = "sql/services/get_service_by_id.sql"
+	addServiceScript     = "sql/services/add_service.sql"
+	updateServiceScript  = "sql/services/update_service.sql"
+	deleteServiceScript  = "sql/services/delete_service.sql"
+)
+
 // GetServiceByID returns a single service under the specified ID.
-func (repo *ServiceRepository) GetServiceByID(id int64) (*Service, error) {
-	script, err := assets.Asset("sql/services/get_service_by_id.sql")
+func (repo *ServiceRepository) GetServiceByID(ctx context.Context, id int64) (*Service, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getServiceByIDScript)
 
 	if err != nil {
 		return nil, err
 	}
 
-	row := repo.db.QueryRow(string(script), id)
+	row := stmt.QueryRowContext(ctx, id)
 	service := new(Service)
-	err = row.Scan(&service.ID, &service.Title, &service.Description, &service.Price)
+	err = row.Scan(&service.ID, &service.Title, &service.Description,
+		&service.Category, &service.Price, &service.Currency)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return service, nil
 }
 
-// GetAllServices returns a set of all services from the database.
-func (repo *ServiceRepository) GetAllServices() ([]*Service, error) {
-	script, err := assets.Asset("sql/services/get_all_services.sql")
+// serviceColumns contains the columns services can be sorted by.
+var serviceColumns = map[string]string{
+	"id":          "s.id",
+	"title":       "s.title",
+	"description": "s.service_description",
+	"category":    "s.category",
+	"price":       "s.price",
+	"currency":    "s.currency",
+}
+
+// GetAllServices returns a single page of services satisfying the filter
+// and the total number of such services in the database.
+func (repo *ServiceRepository) GetAllServices(ctx context.Context, filter *ServiceFilter, params *ListParams) ([]*Service, int64, error) {
+	script := repo.stmts.script(getAllServicesScript)
+	countScript := repo.stmts.script(countServicesScript)
+
+	query := new(listQuery)
+
+	if filter.Title != "" {
+		query.where("s.title ILIKE ?", likePattern(filter.Title))
+	}
+
+	if filter.Category != "" {
+		query.where("s.category = ?", filter.Category)
+	}
+
+	if filter.Currency != "" {
+		query.where("s.currency = ?", filter.Currency)
+	}
+
+	if filter.PriceMin != nil {
+		query.where("s.price >= ?", *filter.PriceMin)
+	}
+
+	if filter.PriceMax != nil {
+		query.where("s.price <= ?", *filter.PriceMax)
+	}
+
+	statement, args, err := query.list(script, "s.id", serviceColumns, params)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	rows, err := repo.db.Query(string(script))
+	rows, err := repo.stmts.query(ctx, repo.db, getAllServicesScript, statement, args...)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, translateError(err)
 	}
+	defer rows.Close()
 
 	services := make([]*Service, 0)
 
 	for rows.Next() {
 		service := new(Service)
-		err = rows.Scan(&service.ID, &service.Title, &service.Description, &service.Price)
+		err = rows.Scan(&service.ID, &service.Title, &service.Description,
+			&service.Category, &service.Price, &service.Currency)
 
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		services = append(services, service)
 	}
 
-	return services, nil
+	if err = rows.Err(); err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	var total int64
+	statement, args = query.count(countScript)
+	err = repo.stmts.queryRow(ctx, repo.db, countServicesScript, statement, args...).Scan(&total)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return services, total, nil
 }
 
-// AddService adds a new service to the database.
-func (repo *ServiceRepository) AddService(service *Service) error {
-	script, err := assets.Asset("sql/services/add_service.sql")
+// AddService adds a new service to the database
+// and fills the service with the stored data including its ID.
+func (repo *ServiceRepository) AddService(ctx context.Context, service *Service) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, addServiceScript)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.db.Exec(string(script), service.Title, service.Description, service.Price)
+	row := stmt.QueryRowContext(ctx, service.Title, service.Description,
+		service.Category, service.Price, service.Currency)
+	err = row.Scan(&service.ID, &service.Title, &service.Description,
+		&service.Category, &service.Price, &service.Currency)
 
-	return err
+	return translateError(err)
 }
 
 // UpdateService updates the service in the database.
-func (repo *ServiceRepository) UpdateService(service *Service) error {
-	script, err := assets.Asset("sql/services/update_service.sql")
+func (repo *ServiceRepository) UpdateService(ctx context.Context, service *Service) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, updateServiceScript)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.db.Exec(string(script), service.ID, service.Title,
-		service.Description, service.Price)
+	result, err := stmt.ExecContext(ctx, service.ID, service.Title,
+		service.Description, service.Category, service.Price, service.Currency)
+
+	if err != nil {
+		return translateError(err)
+	}
 
-	return err
+	return checkAffected(result)
 }
 
 // DeleteService deletes the service from the database.
-func (repo *ServiceRepository) DeleteService(id int64) error {
-	script, err := assets.Asset("sql/services/delete_service.sql")
+func (repo *ServiceRepository) DeleteService(ctx context.Context, id int64) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, deleteServiceScript)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.db.Exec(string(script), id)
+	result, err := stmt.ExecContext(ctx, id)
+
+	if err != nil {
+		return translateError(err)
+	}
 
-	return err
+	return checkAffected(result)
 }
 
 // NewServiceRepo creates a new repository for services.
-func NewServiceRepo(db *sql.DB) *ServiceRepository {
-	return &ServiceRepository{db}
+func NewServiceRepo(db *sql.DB, stmts *Statements) *ServiceRepository {
+	return &ServiceRepository{db, stmts}
 }
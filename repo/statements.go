@@ -0,0 +1,239 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"restApp/assets"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scripts contains the names of all the scripts the repositories use.
+var scripts = []string{
+	getCustomerByIDScript, getAllCustomersScript, countCustomersScript,
+	addCustomerScript, updateCustomerScript, deleteCustomerScript,
+
+	getServiceByIDScript, getAllServicesScript, countServicesScript,
+	addServiceScript, updateServiceScript, deleteServiceScript,
+
+	getOrderByIDScript, getOrderTotalsScript, getAllOrdersScript, countOrdersScript,
+	addOrderScript, updateOrderScript, deleteOrderScript,
+	getLineItemByIDScript, getAllLineItemsScript,
+	addLineItemScript, updateLineItemScript, deleteLineItemScript, deleteLineItemsScript,
+	getOrderStatusScript, lockOrderScript, setOrderStatusScript,
+	addStatusChangeScript, getStatusHistoryScript,
+	getInvoiceByIDScript, getInvoiceLinesScript, getAllInvoicesScript, countInvoicesScript,
+	nextInvoiceNumberScript, addInvoiceScript, addInvoiceLineScript,
+
+	getTaxRateByIDScript, getAllTaxRatesScript, countTaxRatesScript,
+	addTaxRateScript, updateTaxRateScript, deleteTaxRateScript,
+
+	getExchangeRateByIDScript, getAllExchangeRatesScript, countExchangeRatesScript,
+	addExchangeRateScript, updateExchangeRateScript, deleteExchangeRateScript,
+
+	getAPIKeyByHashScript, getAllAPIKeysScript, addAPIKeyScript, revokeAPIKeyScript,
+}
+
+// QueryObserver receives the duration and the result of every query the repositories execute.
+type QueryObserver interface {
+	ObserveQuery(script string, duration time.Duration, err error)
+}
+
+// Statements holds the SQL scripts of the repositories and the statements prepared from them.
+// The statements are prepared on the first use and shared by all the repositories.
+type Statements struct {
+	db       *sql.DB
+	scripts  map[string]string
+	mutex    sync.Mutex
+	prepared map[string]*sql.Stmt
+	observer QueryObserver
+}
+
+// timedStmt is the prepared statement reporting the duration of its queries to the observer.
+type timedStmt struct {
+	*sql.Stmt
+	name  string
+	stmts *Statements
+}
+
+func (stmt *timedStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := stmt.Stmt.ExecContext(ctx, args...)
+	stmt.stmts.observe(stmt.name, start, err)
+
+	return result, err
+}
+
+func (stmt *timedStmt) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := stmt.Stmt.QueryContext(ctx, args...)
+	stmt.stmts.observe(stmt.name, start, err)
+
+	return rows, err
+}
+
+func (stmt *timedStmt) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := stmt.Stmt.QueryRowContext(ctx, args...)
+	stmt.stmts.observe(stmt.name, start, row.Err())
+
+	return row
+}
+
+// SetObserver sets the observer receiving the duration of the queries.
+// It must be called before the repositories are used.
+func (stmts *Statements) SetObserver(observer QueryObserver) {
+	stmts.observer = observer
+}
+
+func (stmts *Statements) observe(name string, start time.Time, err error) {
+	if stmts.observer == nil {
+		return
+	}
+
+	// The absence of the entry is a normal result of the query.
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+
+	stmts.observer.ObserveQuery(name, time.Since(start), err)
+}
+
+// query runs the statement built from the script with the specified name.
+func (stmts *Statements) query(ctx context.Context, db queryer, name, statement string,
+	args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, statement, args...)
+	stmts.observe(name, start, err)
+
+	return rows, err
+}
+
+// queryRow runs the statement built from the script with the specified name
+// expected to return a single row.
+func (stmts *Statements) queryRow(ctx context.Context, db queryer, name, statement string,
+	args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := db.QueryRowContext(ctx, statement, args...)
+	stmts.observe(name, start, row.Err())
+
+	return row
+}
+
+// script returns the text of the script with the specified name.
+func (stmts *Statements) script(name string) string {
+	return stmts.scripts[name]
+}
+
+// stmt returns the statement prepared from the script with the specified name.
+// If db is a transaction, the statement is bound to it.
+func (stmts *Statements) stmt(ctx context.Context, db queryer, name string) (*timedStmt, error) {
+	stmt, err := stmts.prepare(ctx, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tx, ok := db.(*sql.Tx); ok {
+		stmt = tx.StmtContext(ctx, stmt)
+	}
+
+	return &timedStmt{stmt, name, stmts}, nil
+}
+
+func (stmts *Statements) prepare(ctx context.Context, name string) (*sql.Stmt, error) {
+	stmts.mutex.Lock()
+	stmt, ok := stmts.prepared[name]
+	stmts.mutex.Unlock()
+
+	if ok {
+		return stmt, nil
+	}
+
+	script, ok := stmts.scripts[name]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown script: %s", name)
+	}
+
+	// The statement is prepared without holding the lock
+	// so the slow database doesn't block the other statements.
+	stmt, err := stmts.db.PrepareContext(ctx, script)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	stmts.mutex.Lock()
+	defer stmts.mutex.Unlock()
+
+	// The same statement may have been prepared concurrently.
+	if existing, ok := stmts.prepared[name]; ok {
+		stmt.Close()
+
+		return existing, nil
+	}
+
+	stmts.prepared[name] = stmt
+
+	return stmt, nil
+}
+
+// PrepareAll prepares all the statements that aren't prepared yet.
+// It fails if any of the scripts doesn't match the database schema.
+func (stmts *Statements) PrepareAll(ctx context.Context) error {
+	for _, name := range scripts {
+		if _, err := stmts.prepare(ctx, name); err != nil {
+			return fmt.Errorf("couldn't prepare %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes all the prepared statements.
+func (stmts *Statements) Close() error {
+	stmts.mutex.Lock()
+	defer stmts.mutex.Unlock()
+
+	var firstErr error
+
+	for name, stmt := range stmts.prepared {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		delete(stmts.prepared, name)
+	}
+
+	return firstErr
+}
+
+// NewStatements loads all the scripts the repositories use.
+// It fails if any of the scripts is missing or empty.
+func NewStatements(db *sql.DB) (*Statements, error) {
+	stmts := new(Statements)
+
+	stmts.db = db
+	stmts.scripts = make(map[string]string, len(scripts))
+	stmts.prepared = make(map[string]*sql.Stmt, len(scripts))
+
+	for _, name := range scripts {
+		script, err := assets.FS.ReadFile(name)
+
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load script: %w", err)
+		}
+
+		if strings.TrimSpace(string(script)) == "" {
+			return nil, fmt.Errorf("script %s is empty", name)
+		}
+
+		stmts.scripts[name] = string(script)
+	}
+
+	return stmts, nil
+}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Statuses of the orders. A new order is a draft, and only the drafts can be changed.
+const (
+	StatusDraft      = "draft"
+	StatusConfirmed  = "confirmed"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusCancelled  = "cancelled"
+)
+
+// Actions moving the orders between the statuses.
+const (
+	ActionConfirm  = "confirm"
+	ActionStart    = "start"
+	ActionComplete = "complete"
+	ActionCancel   = "cancel"
+)
+
+// ErrInvalidStatus is returned when the operation isn't allowed
+// in the current status of the order.
+var ErrInvalidStatus = errors.New("operation not allowed in the order status")
+
+// StatusError is returned when the operation isn't allowed in the current status of the order.
+// It wraps ErrInvalidStatus.
+type StatusError struct {
+	Status  string
+	Message string
+}
+
+func (err *StatusError) Error() string {
+	return err.Message
+}
+
+// Unwrap returns ErrInvalidStatus.
+func (err *StatusError) Unwrap() error {
+	return ErrInvalidStatus
+}
+
+// orderTransition moves the order to the status from any of the listed ones.
+type orderTransition struct {
+	from []string
+	to   string
+	// done describes the order after the action in the error messages.
+	done string
+}
+
+// orderTransitions are the only allowed moves between the statuses.
+// The completed and the cancelled orders never change.
+var orderTransitions = map[string]orderTransition{
+	ActionConfirm:  {[]string{StatusDraft}, StatusConfirmed, "confirmed"},
+	ActionStart:    {[]string{StatusConfirmed}, StatusInProgress, "started"},
+	ActionComplete: {[]string{StatusInProgress}, StatusCompleted, "completed"},
+	ActionCancel:   {[]string{StatusDraft, StatusConfirmed, StatusInProgress}, StatusCancelled, "cancelled"},
+}
+
+// IsOrderStatus checks if the value is one of the statuses of the orders.
+func IsOrderStatus(value string) bool {
+	switch value {
+	case StatusDraft, StatusConfirmed, StatusInProgress, StatusCompleted, StatusCancelled:
+		return true
+	}
+
+	return false
+}
+
+// nextStatus returns the status the action moves the order to from the current one.
+func nextStatus(current, action string) (string, error) {
+	transition, ok := orderTransitions[action]
+
+	if !ok {
+		return "", fmt.Errorf("unknown order action: %s", action)
+	}
+
+	if !containsString(transition.from, current) {
+		return "", newStatusError(current, transition.done)
+	}
+
+	return transition.to, nil
+}
+
+// checkEditable returns a *StatusError unless the order and its line items
+// can be changed in the status.
+func checkEditable(status string) error {
+	if status != StatusDraft {
+		return newStatusError(status, "changed")
+	}
+
+	return nil
+}
+
+// checkDeletable returns a *StatusError unless the order can be deleted in the status.
+// The orders that went beyond the draft must be cancelled first.
+func checkDeletable(status string) error {
+	if status != StatusDraft && status != StatusCancelled {
+		return newStatusError(status, "deleted")
+	}
+
+	return nil
+}
+
+// checkInvoiceable returns a *StatusError unless the order can be invoiced in the status.
+// The drafts may still change, and the cancelled orders aren't billed.
+func checkInvoiceable(status string) error {
+	if status == StatusDraft || status == StatusCancelled {
+		return newStatusError(status, "invoiced")
+	}
+
+	return nil
+}
+
+func newStatusError(status, done string) *StatusError {
+	return &StatusError{
+		Status:  status,
+		Message: fmt.Sprintf("the order is %s and can't be %s", strings.ReplaceAll(status, "_", " "), done),
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+)
+
+var allStatuses = []string{StatusDraft, StatusConfirmed, StatusInProgress, StatusCompleted, StatusCancelled}
+
+func TestNextStatus(t *testing.T) {
+	// allowed maps the actions to the statuses they move the orders to from the listed ones.
+	allowed := map[string]map[string]string{
+		ActionConfirm:  {StatusDraft: StatusConfirmed},
+		ActionStart:    {StatusConfirmed: StatusInProgress},
+		ActionComplete: {StatusInProgress: StatusCompleted},
+		ActionCancel: {
+			StatusDraft:      StatusCancelled,
+			StatusConfirmed:  StatusCancelled,
+			StatusInProgress: StatusCancelled,
+		},
+	}
+
+	for action, moves := range allowed {
+		for _, current := range allStatuses {
+			got, err := nextStatus(current, action)
+			want, ok := moves[current]
+
+			if ok {
+				if err != nil || got != want {
+					t.Errorf("nextStatus(%q, %q) = %q, %v, want %q", current, action, got, err, want)
+				}
+
+				continue
+			}
+
+			var statusErr *StatusError
+
+			if !errors.As(err, &statusErr) || !errors.Is(err, ErrInvalidStatus) {
+				t.Errorf("nextStatus(%q, %q) error = %v, want a *StatusError", current, action, err)
+				continue
+			}
+
+			if statusErr.Status != current {
+				t.Errorf("nextStatus(%q, %q) error status = %q", current, action, statusErr.Status)
+			}
+		}
+	}
+
+	_, err := nextStatus(StatusDraft, "archive")
+
+	if err == nil || errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("unknown action error = %v, want a plain error", err)
+	}
+}
+
+func TestStatusChecks(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(status string) error
+		// allowed are the statuses the check passes.
+		allowed map[string]bool
+	}{
+		{"checkEditable", checkEditable, map[string]bool{StatusDraft: true}},
+		{"checkDeletable", checkDeletable, map[string]bool{StatusDraft: true, StatusCancelled: true}},
+		{"checkInvoiceable", checkInvoiceable,
+			map[string]bool{StatusConfirmed: true, StatusInProgress: true, StatusCompleted: true}},
+	}
+
+	for _, test := range tests {
+		for _, status := range allStatuses {
+			err := test.check(status)
+
+			if test.allowed[status] && err != nil {
+				t.Errorf("%s(%q) = %v, want nil", test.name, status, err)
+			}
+
+			if !test.allowed[status] && !errors.Is(err, ErrInvalidStatus) {
+				t.Errorf("%s(%q) = %v, want %v", test.name, status, err, ErrInvalidStatus)
+			}
+		}
+	}
+}
+
+func TestStatusErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{checkEditable(StatusInProgress), "the order is in progress and can't be changed"},
+		{checkDeletable(StatusCompleted), "the order is completed and can't be deleted"},
+		{checkInvoiceable(StatusDraft), "the order is draft and can't be invoiced"},
+	}
+
+	for _, test := range tests {
+		if test.err == nil || test.err.Error() != test.want {
+			t.Errorf("error = %v, want %q", test.err, test.want)
+		}
+	}
+
+	_, err := nextStatus(StatusCompleted, ActionCancel)
+
+	if want := "the order is completed and can't be cancelled"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestIsOrderStatus(t *testing.T) {
+	for _, status := range allStatuses {
+		if !IsOrderStatus(status) {
+			t.Errorf("IsOrderStatus(%q) = false", status)
+		}
+	}
+
+	for _, value := range []string{"", "Draft", "in progress", "canceled", ActionConfirm} {
+		if IsOrderStatus(value) {
+			t.Errorf("IsOrderStatus(%q) = true", value)
+		}
+	}
+}
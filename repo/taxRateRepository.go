@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TaxRateRepository represents a data repository and implements CRUD methods for tax rates.
+type TaxRateRepository struct {
+	db    queryer
+	stmts *Statements
+}
+
+// Scripts used by the tax rate repository.
+const (
+	getAllTaxRatesScript = "sql/tax_rates/get_all_tax_rates.sql"
+	countTaxRatesScript  = "sql/tax_rates/count_tax_rates.sql"
+	getTaxRateByIDScript = "sql/tax_rates/get_tax_rate_by_id.sql"
+	addTaxRateScript     = "sql/tax_rates/add_tax_rate.sql"
+	updateTaxRateScript  = "sql/tax_rates/update_tax_rate.sql"
+	deleteTaxRateScript  = "sql/tax_rates/delete_tax_rate.sql"
+)
+
+// GetTaxRateByID returns a single tax rate under the specified ID.
+func (repo *TaxRateRepository) GetTaxRateByID(ctx context.Context, id int64) (*TaxRate, error) {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, getTaxRateByIDScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	row := stmt.QueryRowContext(ctx, id)
+	rate := new(TaxRate)
+	err = row.Scan(&rate.ID, &rate.Category, &rate.Jurisdiction, &rate.Rate)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return rate, nil
+}
+
+// taxRateColumns contains the columns tax rates can be sorted by.
+var taxRateColumns = map[string]string{
+	"id":           "t.id",
+	"category":     "t.category",
+	"jurisdiction": "t.jurisdiction",
+	"rate":         "t.rate",
+}
+
+// GetAllTaxRates returns a single page of tax rates satisfying the filter
+// and the total number of such rates in the database.
+func (repo *TaxRateRepository) GetAllTaxRates(ctx context.Context, filter *TaxRateFilter, params *ListParams) ([]*TaxRate, int64, error) {
+	script := repo.stmts.script(getAllTaxRatesScript)
+	countScript := repo.stmts.script(countTaxRatesScript)
+
+	query := new(listQuery)
+
+	if filter.Category != "" {
+		query.where("t.category = ?", filter.Category)
+	}
+
+	if filter.Jurisdiction != "" {
+		query.where("t.jurisdiction = ?", filter.Jurisdiction)
+	}
+
+	statement, args, err := query.list(script, "t.id", taxRateColumns, params)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := repo.stmts.query(ctx, repo.db, getAllTaxRatesScript, statement, args...)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	defer rows.Close()
+
+	rates := make([]*TaxRate, 0)
+
+	for rows.Next() {
+		rate := new(TaxRate)
+		err = rows.Scan(&rate.ID, &rate.Category, &rate.Jurisdiction, &rate.Rate)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	var total int64
+	statement, args = query.count(countScript)
+	err = repo.stmts.queryRow(ctx, repo.db, countTaxRatesScript, statement, args...).Scan(&total)
+
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return rates, total, nil
+}
+
+// AddTaxRate adds a new tax rate to the database
+// and fills the rate with the stored data including its ID.
+func (repo *TaxRateRepository) AddTaxRate(ctx context.Context, rate *TaxRate) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, addTaxRateScript)
+
+	if err != nil {
+		return err
+	}
+
+	row := stmt.QueryRowContext(ctx, rate.Category, rate.Jurisdiction, rate.Rate)
+	err = row.Scan(&rate.ID, &rate.Category, &rate.Jurisdiction, &rate.Rate)
+
+	return translateError(err)
+}
+
+// UpdateTaxRate updates the tax rate in the database.
+// The line items already added to the orders keep their rates.
+func (repo *TaxRateRepository) UpdateTaxRate(ctx context.Context, rate *TaxRate) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, updateTaxRateScript)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, rate.ID, rate.Category, rate.Jurisdiction, rate.Rate)
+
+	if err != nil {
+		return translateError(err)
+	}
+
+	return checkAffected(result)
+}
+
+// DeleteTaxRate deletes the tax rate from the database.
+func (repo *TaxRateRepository) DeleteTaxRate(ctx context.Context, id int64) error {
+	stmt, err := repo.stmts.stmt(ctx, repo.db, deleteTaxRateScript)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, id)
+
+	if err != nil {
+		return translateError(err)
+	}
+
+	return checkAffected(result)
+}
+
+// NewTaxRateRepo creates a new repository for tax rates.
+func NewTaxRateRepo(db *sql.DB, stmts *Statements) *TaxRateRepository {
+	return &TaxRateRepository{db, stmts}
+}
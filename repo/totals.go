@@ -0,0 +1,47 @@
+package repo
+
+// Totals are the amounts the order costs. The discounts and the taxes are rounded
+// to a cent for every line before they are summed up. The order_line_amounts view
+// computes the totals stored in the database the same way.
+type Totals struct {
+	Currency string `json:"currency,omitempty"`
+	Subtotal Money  `json:"subtotal"`
+	Tax      Money  `json:"tax"`
+	Total    Money  `json:"total"`
+}
+
+// Amount returns the amount of the line item before the discount and the taxes.
+func (item *LineItem) Amount() Money {
+	return item.UnitPrice.Times(item.Quantity)
+}
+
+// NetAmount returns the amount of the line item after the discount.
+func (item *LineItem) NetAmount() Money {
+	amount := item.Amount()
+	return amount - amount.Percent(item.Discount)
+}
+
+// TaxAmount returns the tax on the net amount of the line item.
+func (item *LineItem) TaxAmount() Money {
+	return item.NetAmount().Percent(item.TaxRate)
+}
+
+// ComputeTotals returns the totals of the order with the line items.
+// It returns nil if the items are in several currencies.
+func ComputeTotals(items []*LineItem) *Totals {
+	totals := new(Totals)
+
+	for _, item := range items {
+		if totals.Currency != "" && totals.Currency != item.Currency {
+			return nil
+		}
+
+		totals.Currency = item.Currency
+		totals.Subtotal += item.NetAmount()
+		totals.Tax += item.TaxAmount()
+	}
+
+	totals.Total = totals.Subtotal + totals.Tax
+
+	return totals
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// retryDelay is the base delay before retrying the transaction
+// failed due to a concurrent one. It doubles on each attempt.
+const retryDelay = 10 * time.Millisecond
+
+// queryer is the set of methods shared by *sql.DB and *sql.Tx
+// the repositories use to access the database.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Repos is a set of repositories bound to the same transaction.
+type Repos struct {
+	Customers ICustomerRepository
+	Services  IServiceRepository
+	Orders    IOrderRepository
+}
+
+// UnitOfWork runs operations spanning multiple repositories in a single transaction.
+type UnitOfWork struct {
+	db        *sql.DB
+	stmts     *Statements
+	isolation sql.IsolationLevel
+	retries   int
+}
+
+// WithTx runs the function in a new transaction with the default isolation level.
+// See WithTxOptions.
+func (uow *UnitOfWork) WithTx(ctx context.Context, fn func(repos *Repos) error) error {
+	return uow.WithTxOptions(ctx, &sql.TxOptions{Isolation: uow.isolation}, fn)
+}
+
+// WithTxOptions runs the function in a new transaction with the specified options.
+// The repositories passed to the function are bound to the transaction.
+// The transaction is committed if the function returns nil and rolled back otherwise.
+// If the transaction fails due to a serialization failure or a deadlock,
+// it's retried with the function called again.
+func (uow *UnitOfWork) WithTxOptions(ctx context.Context, opts *sql.TxOptions,
+	fn func(repos *Repos) error) error {
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		err = uow.run(ctx, opts, fn)
+
+		if !errors.Is(err, ErrTxConflict) || attempt >= uow.retries {
+			return err
+		}
+
+		delay := retryDelay << uint(attempt)
+		delay += time.Duration(rand.Int63n(int64(delay)))
+
+		select {
+		case <-ctx.Done():
+			return err
+
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (uow *UnitOfWork) run(ctx context.Context, opts *sql.TxOptions,
+	fn func(repos *Repos) error) error {
+	tx, err := uow.db.BeginTx(ctx, opts)
+
+	if err != nil {
+		return translateError(err)
+	}
+	// Rollback does nothing if the transaction is committed.
+	defer tx.Rollback()
+
+	repos := &Repos{
+		Customers: &CustomerRepository{tx, uow.stmts},
+		Services:  &ServiceRepository{tx, uow.stmts},
+		Orders:    &OrderRepository{tx, uow.stmts},
+	}
+
+	if err = fn(repos); err != nil {
+		return err
+	}
+
+	return translateError(tx.Commit())
+}
+
+// inTx runs the function in the transaction the repository is bound to
+// or in a new one if the repository is bound to the database itself.
+func inTx(ctx context.Context, db queryer, fn func(tx queryer) error) error {
+	conn, ok := db.(*sql.DB)
+
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return translateError(err)
+	}
+	// Rollback does nothing if the transaction is committed.
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return translateError(tx.Commit())
+}
+
+// ParseIsolationLevel converts the name of the isolation level like "read-committed"
+// or "serializable" into the level itself.
+func ParseIsolationLevel(name string) (sql.IsolationLevel, error) {
+	levels := map[string]sql.IsolationLevel{
+		"default":          sql.LevelDefault,
+		"read-uncommitted": sql.LevelReadUncommitted,
+		"read-committed":   sql.LevelReadCommitted,
+		"repeatable-read":  sql.LevelRepeatableRead,
+		"serializable":     sql.LevelSerializable,
+	}
+	level, ok := levels[strings.ToLower(strings.ReplaceAll(name, " ", "-"))]
+
+	if !ok {
+		return sql.LevelDefault, fmt.Errorf("unknown isolation level: %s", name)
+	}
+
+	return level, nil
+}
+
+// NewUnitOfWork creates a new unit of work running the transactions
+// with the specified default isolation level. The transactions failed
+// due to concurrent ones are retried the specified number of times.
+func NewUnitOfWork(db *sql.DB, stmts *Statements,
+	isolation sql.IsolationLevel, retries int) *UnitOfWork {
+	return &UnitOfWork{db, stmts, isolation, retries}
+}
@@ -0,0 +1,342 @@
+package repo
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits of the fields imposed by the database schema.
+const (
+	maxNameLength        = 128
+	maxAddressLength     = 256
+	maxEmailLength       = 256
+	maxTitleLength       = 256
+	maxDescriptionLength = 512
+	maxNotesLength       = 512
+	maxCategoryLength    = 64
+	maxTermsLength       = 256
+	maxPrice             = Money(999999999)
+	maxPercent           = Percent(100000)
+	maxRate              = Rate(999999999999999999)
+)
+
+// currencyPattern matches the ISO 4217 currency codes.
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// phonePattern matches phone numbers in the E.164 format
+// short enough to fit into the phone_number column.
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,12}$`)
+
+// Weights of the digits to compute the check digits of the tax ID.
+var (
+	taxIDWeights10 = []int{2, 4, 10, 3, 5, 9, 4, 6, 8}
+	taxIDWeights11 = []int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
+	taxIDWeights12 = []int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
+)
+
+// FieldError describes a single invalid field of the model.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError is returned when the model has invalid fields.
+// It contains all the invalid fields at once.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (err *ValidationError) Error() string {
+	messages := make([]string, 0, len(err.Fields))
+
+	for _, field := range err.Fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field.Field, field.Message))
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// validator collects the errors of the model fields.
+type validator struct {
+	errors []FieldError
+}
+
+func (v *validator) fail(field, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{field, fmt.Sprintf(format, args...)})
+}
+
+// text checks if the required text field isn't empty and fits into the column.
+// It returns false if the field is invalid.
+func (v *validator) text(field, value string, maxLength int) bool {
+	if strings.TrimSpace(value) == "" {
+		v.fail(field, "must not be empty")
+		return false
+	}
+
+	if utf8.RuneCountInString(value) > maxLength {
+		v.fail(field, "must be at most %d characters long", maxLength)
+		return false
+	}
+
+	return true
+}
+
+// currency checks if the field is an ISO 4217 currency code.
+func (v *validator) currency(field, value string) {
+	if !IsCurrency(value) {
+		v.fail(field, "must be a 3-letter ISO 4217 currency code, e.g. EUR")
+	}
+}
+
+// maxLength checks if the optional text field fits into the column.
+func (v *validator) maxLength(field, value string, maxLength int) {
+	if utf8.RuneCountInString(value) > maxLength {
+		v.fail(field, "must be at most %d characters long", maxLength)
+	}
+}
+
+func (v *validator) result() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Fields: v.errors}
+}
+
+// Validate checks if the customer can be stored in the database.
+func (customer *Customer) Validate() error {
+	v := new(validator)
+
+	v.text("name", customer.Name, maxNameLength)
+	v.text("address", customer.Address, maxAddressLength)
+
+	// CHAR columns are read from the database padded with spaces.
+	if taxID := strings.TrimRight(customer.TaxID, " "); taxID == "" {
+		v.fail("tax_id", "must not be empty")
+	} else if !validTaxID(taxID) {
+		v.fail("tax_id", "must be a valid 10 or 12 digit tax ID")
+	}
+
+	if v.text("email", customer.Email, maxEmailLength) && !validEmail(customer.Email) {
+		v.fail("email", "must be a valid email address")
+	}
+
+	if phone := strings.TrimRight(customer.PhoneNumber, " "); phone == "" {
+		v.fail("phone_number", "must not be empty")
+	} else if !phonePattern.MatchString(phone) {
+		v.fail("phone_number", "must be a phone number in the E.164 format, e.g. +79161234567")
+	}
+
+	v.maxLength("jurisdiction", customer.Jurisdiction, maxCategoryLength)
+
+	return v.result()
+}
+
+// Validate checks if the service can be stored in the database.
+func (service *Service) Validate() error {
+	v := new(validator)
+
+	v.text("title", service.Title, maxTitleLength)
+	v.text("description", service.Description, maxDescriptionLength)
+	v.maxLength("category", service.Category, maxCategoryLength)
+
+	switch {
+	case service.Price <= 0:
+		v.fail("price", "must be positive")
+
+	case service.Price > maxPrice:
+		v.fail("price", "must not exceed %s", maxPrice)
+	}
+
+	v.currency("currency", service.Currency)
+
+	return v.result()
+}
+
+// Validate checks if the order can be stored in the database.
+func (order *Order) Validate() error {
+	v := new(validator)
+	order.validate(v)
+
+	return v.result()
+}
+
+func (order *Order) validate(v *validator) {
+	if order.CustomerID <= 0 {
+		v.fail("customer_id", "must be a positive customer ID")
+	}
+
+	if order.Date.IsZero() {
+		v.fail("date", "must not be empty")
+	} else {
+		year, month, day := time.Now().Date()
+		endOfToday := time.Date(year, month, day+1, 0, 0, 0, 0, time.Local)
+
+		if !order.Date.Before(endOfToday) {
+			v.fail("date", "must not be in the future")
+		}
+	}
+}
+
+// Validate checks if the order and its line items can be stored in the database.
+func (order *OrderWithServices) Validate() error {
+	v := new(validator)
+	order.Order.validate(v)
+
+	for i, item := range order.Services {
+		field := fmt.Sprintf("services[%d]", i)
+
+		if item == nil {
+			v.fail(field, "must not be null")
+			continue
+		}
+
+		item.validate(v, field+".")
+	}
+
+	return v.result()
+}
+
+// Validate checks if the line item can be stored in the database.
+func (item *LineItem) Validate() error {
+	v := new(validator)
+	item.validate(v, "")
+
+	return v.result()
+}
+
+// validate checks the fields of the line item naming them with the prefix.
+// The unit price isn't checked as it's taken from the service.
+func (item *LineItem) validate(v *validator, prefix string) {
+	if item.ServiceID <= 0 {
+		v.fail(prefix+"service_id", "must be a positive service ID")
+	}
+
+	if item.Quantity <= 0 {
+		v.fail(prefix+"quantity", "must be positive")
+	}
+
+	switch {
+	case item.Discount < 0 || item.Discount > maxPercent:
+		v.fail(prefix+"discount", "must be a percentage between 0 and 100")
+
+	// The discount column has fewer decimal places than the percentages.
+	case item.Discount%10 != 0:
+		v.fail(prefix+"discount", "must have at most 2 decimal places")
+	}
+
+	v.maxLength(prefix+"notes", item.Notes, maxNotesLength)
+}
+
+// Validate checks if the tax rate can be stored in the database.
+func (rate *TaxRate) Validate() error {
+	v := new(validator)
+
+	v.maxLength("category", rate.Category, maxCategoryLength)
+	v.maxLength("jurisdiction", rate.Jurisdiction, maxCategoryLength)
+
+	if rate.Rate < 0 || rate.Rate > maxPercent {
+		v.fail("rate", "must be a percentage between 0 and 100")
+	}
+
+	return v.result()
+}
+
+// Validate checks if the exchange rate can be stored in the database.
+func (rate *ExchangeRate) Validate() error {
+	v := new(validator)
+
+	v.currency("base_currency", rate.BaseCurrency)
+	v.currency("quote_currency", rate.QuoteCurrency)
+
+	if IsCurrency(rate.BaseCurrency) && rate.BaseCurrency == rate.QuoteCurrency {
+		v.fail("quote_currency", "must differ from the base currency")
+	}
+
+	switch {
+	case rate.Rate <= 0:
+		v.fail("rate", "must be positive")
+
+	case rate.Rate > maxRate:
+		v.fail("rate", "must not exceed %s", maxRate)
+	}
+
+	if rate.EffectiveDate.IsZero() {
+		v.fail("effective_date", "must not be empty")
+	}
+
+	return v.result()
+}
+
+// Validate checks if the terms and the issuer of the invoice can be stored in the database.
+// The rest of the invoice is copied from the order.
+func (invoice *Invoice) Validate() error {
+	v := new(validator)
+
+	v.maxLength("issuer.name", invoice.Issuer.Name, maxNameLength)
+	v.maxLength("issuer.address", invoice.Issuer.Address, maxAddressLength)
+
+	if taxID := invoice.Issuer.TaxID; taxID != "" && !validTaxID(taxID) {
+		v.fail("issuer.tax_id", "must be a valid 10 or 12 digit tax ID")
+	}
+
+	if invoice.DueDate.IsZero() {
+		v.fail("due_date", "must not be empty")
+	} else if invoice.DueDate.Before(invoice.IssueDate) {
+		v.fail("due_date", "must not be before the issue date")
+	}
+
+	v.maxLength("payment_terms", invoice.PaymentTerms, maxTermsLength)
+
+	return v.result()
+}
+
+// IsCurrency checks if the value is an ISO 4217 currency code.
+func IsCurrency(value string) bool {
+	return currencyPattern.MatchString(value)
+}
+
+// validEmail checks if the value is a bare email address
+// without the display name and the angle brackets.
+func validEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
+
+// validTaxID checks the length and the check digits of the tax ID (INN).
+func validTaxID(value string) bool {
+	digits := make([]int, 0, len(value))
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+
+		digits = append(digits, int(r-'0'))
+	}
+
+	switch len(digits) {
+	case 10:
+		return checkDigit(digits, taxIDWeights10) == digits[9]
+
+	case 12:
+		return checkDigit(digits, taxIDWeights11) == digits[10] &&
+			checkDigit(digits, taxIDWeights12) == digits[11]
+	}
+
+	return false
+}
+
+func checkDigit(digits, weights []int) int {
+	sum := 0
+
+	for i, weight := range weights {
+		sum += digits[i] * weight
+	}
+
+	return sum % 11 % 10
+}
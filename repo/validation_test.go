@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidTaxID(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"7707083893", true},
+		{"7830002293", true},
+		{"500100732259", true},
+		// The check digit of the 10-digit tax ID is wrong.
+		{"7707083894", false},
+		// The 12th digit is wrong.
+		{"500100732258", false},
+		// The 11th digit is wrong while the 12th one matches it.
+		{"500100732266", false},
+		{"", false},
+		{"770708389", false},
+		{"77070838930", false},
+		{"5001007322590", false},
+		{"770708389a", false},
+		{" 7707083893", false},
+		{"-707083893", false},
+		// Only the ASCII digits are allowed.
+		{"７７０７０８３８９３", false},
+	}
+
+	for _, test := range tests {
+		if got := validTaxID(test.value); got != test.valid {
+			t.Errorf("validTaxID(%q) = %v, want %v", test.value, got, test.valid)
+		}
+	}
+}
+
+func TestCustomerValidate(t *testing.T) {
+	valid := func() *Customer {
+		return &Customer{
+			Name:        "Romashka LLC",
+			Address:     "1 Lenina St, Moscow",
+			TaxID:       "7707083893",
+			Email:       "info@romashka.example",
+			PhoneNumber: "+79161234567",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(customer *Customer)
+		fields []string
+	}{
+		{"valid", func(c *Customer) {}, nil},
+		// CHAR columns are read from the database padded with spaces.
+		{"padded tax ID", func(c *Customer) { c.TaxID += "  " }, nil},
+		{"padded phone number", func(c *Customer) { c.PhoneNumber += "  " }, nil},
+		{"empty", func(c *Customer) { *c = Customer{} },
+			[]string{"name", "address", "tax_id", "email", "phone_number"}},
+		{"blank name", func(c *Customer) { c.Name = "   " }, []string{"name"}},
+		{"long name", func(c *Customer) { c.Name = strings.Repeat("я", maxNameLength+1) }, []string{"name"}},
+		{"longest name", func(c *Customer) { c.Name = strings.Repeat("я", maxNameLength) }, nil},
+		{"invalid tax ID", func(c *Customer) { c.TaxID = "7707083894" }, []string{"tax_id"}},
+		{"email with name", func(c *Customer) { c.Email = "Info <info@romashka.example>" }, []string{"email"}},
+		{"not an email", func(c *Customer) { c.Email = "romashka" }, []string{"email"}},
+		{"phone without plus", func(c *Customer) { c.PhoneNumber = "89161234567" }, []string{"phone_number"}},
+		{"phone with spaces", func(c *Customer) { c.PhoneNumber = "+7 916 123 45 67" }, []string{"phone_number"}},
+		{"long jurisdiction", func(c *Customer) { c.Jurisdiction = strings.Repeat("x", maxCategoryLength+1) },
+			[]string{"jurisdiction"}},
+	}
+
+	for _, test := range tests {
+		customer := valid()
+		test.change(customer)
+		err := customer.Validate()
+
+		if test.fields == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+
+			continue
+		}
+
+		var validationErr *ValidationError
+
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s: error = %v, want a *ValidationError", test.name, err)
+			continue
+		}
+
+		fields := make([]string, 0, len(validationErr.Fields))
+
+		for _, field := range validationErr.Fields {
+			fields = append(fields, field.Field)
+		}
+
+		if !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("%s: invalid fields = %q, want %q", test.name, fields, test.fields)
+		}
+	}
+}
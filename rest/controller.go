@@ -1,9 +1,16 @@
 package rest
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"path"
+	"restApp/logging"
+	"restApp/repo"
+	"strconv"
 
 	"github.com/gorilla/mux"
 )
@@ -14,28 +21,233 @@ type Controller interface {
 }
 
 type controller struct {
-	logger *log.Logger
+	logger *logging.Logger
 }
 
-func (ctl *controller) handleInternalError(message string, err error) {
+// message is a JSON reply to the client carrying a human-readable message.
+type message struct {
+	Message string `json:"message"`
+}
+
+// log returns the logger adding the ID of the request to the entries.
+func (ctl *controller) log(r *http.Request) *logging.Logger {
+	return ctl.logger.With("request_id", requestID(r))
+}
+
+func (ctl *controller) handleInternalError(r *http.Request, message string, err error) {
 	if err != nil {
-		ctl.logger.Printf("Error occured: %s, %s\n", message, err)
+		ctl.log(r).Error(message, "error", err)
 	}
 }
 
-func (ctl *controller) handleWebError(w http.ResponseWriter, statusCode int, message string) {
-	mes := fmt.Sprintf("%d - %s", statusCode, message)
+func (ctl *controller) handleWebError(w http.ResponseWriter, r *http.Request,
+	statusCode int, code, message string) {
+	ctl.sendError(w, r, statusCode, errorBody{Code: code, Message: message})
+}
 
-	ctl.logger.Println("Sent error message to the client:", mes)
-	http.Error(w, mes, statusCode)
+// handleParamError replies to the client about the incorrect request parameter.
+func (ctl *controller) handleParamError(w http.ResponseWriter, r *http.Request, err error) {
+	body := errorBody{Code: codeInvalidParameter, Message: err.Error()}
+	var paramErr *paramError
+
+	if errors.As(err, &paramErr) {
+		body.Details = []errorDetail{{Field: paramErr.field, Message: paramErr.message}}
+	}
+
+	ctl.sendError(w, r, http.StatusBadRequest, body)
 }
 
-func (ctl *controller) sendSuccess(w http.ResponseWriter, message string) {
-	http.Error(w, message, http.StatusOK)
+// handleValidationError replies to the client with all the invalid fields of the model.
+func (ctl *controller) handleValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	body := errorBody{Code: codeValidationFailed, Message: "The data is invalid"}
+	var validationErr *repo.ValidationError
+
+	if errors.As(err, &validationErr) {
+		for _, field := range validationErr.Fields {
+			body.Details = append(body.Details, errorDetail{Field: field.Field, Message: field.Message})
+		}
+	}
+
+	ctl.sendError(w, r, http.StatusUnprocessableEntity, body)
+}
+
+// patchEntity applies the patch from the request body to the current state
+// of the entity and stores the result in the patched entity. It replies
+// to the client with the error and returns false if the patch can't be applied.
+func (ctl *controller) patchEntity(w http.ResponseWriter, r *http.Request,
+	patch []byte, current, patched interface{}) bool {
+	document, err := json.Marshal(current)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return false
+	}
+
+	document, err = applyPatch(r.Header.Get("Content-Type"), document, patch)
+
+	switch {
+	case errors.Is(err, errUnsupportedPatch):
+		ctl.handleWebError(w, r, http.StatusUnsupportedMediaType, codeUnsupportedMediaType,
+			fmt.Sprintf("The patch must be of type %s or %s", mergePatchType, jsonPatchType))
+
+		return false
+
+	case errors.Is(err, errPatchTestFailed):
+		ctl.handleWebError(w, r, http.StatusConflict, codePatchTestFailed, err.Error())
+
+		return false
+
+	case err != nil:
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidPatch, err.Error())
+
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	if err = decoder.Decode(patched); err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidPatch,
+			fmt.Sprintf("The patched entity is incorrect: %s", err))
+
+		return false
+	}
+
+	return true
+}
+
+// resourceLocation returns the location of the resource
+// with the specified ID created in the collection requested.
+func resourceLocation(r *http.Request, id int64) string {
+	return path.Join(r.URL.Path, strconv.FormatInt(id, 10))
+}
+
+// handleRepoError replies to the client with the error corresponding to the error
+// returned by the repository. The notFound message is sent if the entry doesn't exist.
+func (ctl *controller) handleRepoError(w http.ResponseWriter, r *http.Request,
+	err error, notFound string) {
+	var body errorBody
+	var statusCode int
+
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		statusCode = http.StatusNotFound
+		body = errorBody{Code: codeNotFound, Message: notFound}
+
+	case errors.Is(err, repo.ErrUniqueViolation):
+		statusCode = http.StatusConflict
+		body = errorBody{Code: codeAlreadyExists,
+			Message: "An entry with the same unique field already exists"}
+
+	case errors.Is(err, repo.ErrForeignKeyViolation) && r.Method == http.MethodDelete:
+		statusCode = http.StatusConflict
+		body = errorBody{Code: codeReferenced,
+			Message: "The entry is referred by other entries"}
+
+	case errors.Is(err, repo.ErrForeignKeyViolation):
+		statusCode = http.StatusUnprocessableEntity
+		body = errorBody{Code: codeInvalidReference,
+			Message: "The referred entry doesn't exist"}
+
+	case errors.Is(err, repo.ErrCheckViolation):
+		statusCode = http.StatusUnprocessableEntity
+		body = errorBody{Code: codeInvalidData,
+			Message: "The data doesn't satisfy the constraints"}
+
+	case errors.Is(err, repo.ErrNoExchangeRate):
+		statusCode = http.StatusUnprocessableEntity
+		body = errorBody{Code: codeNoExchangeRate,
+			Message: "The amounts can't be converted to the currency",
+			Details: []errorDetail{{Field: "currency", Message: err.Error()}}}
+
+	case errors.Is(err, repo.ErrInvalidStatus):
+		statusCode = http.StatusConflict
+		body = errorBody{Code: codeInvalidStatus,
+			Message: "The operation isn't allowed in the current status of the order",
+			Details: []errorDetail{{Field: "status", Message: err.Error()}}}
+
+	case errors.Is(err, repo.ErrTxConflict):
+		statusCode = http.StatusConflict
+		body = errorBody{Code: codeConflict,
+			Message: "The request conflicted with a concurrent one, please retry"}
+
+	case errors.Is(err, repo.ErrCanceled) && errors.Is(r.Context().Err(), context.Canceled):
+		// The client has gone away, so there is nobody to reply to.
+		ctl.log(r).Info("The request is canceled by the client", "error", err)
+
+		return
+
+	case errors.Is(err, repo.ErrCanceled):
+		statusCode = http.StatusGatewayTimeout
+		body = errorBody{Code: codeTimeout,
+			Message: "The request took too long to process"}
+
+	case errors.Is(err, repo.ErrUnavailable):
+		ctl.handleInternalError(r, "Database is unavailable", err)
+		statusCode = http.StatusServiceUnavailable
+		body = errorBody{Code: codeUnavailable,
+			Message: "The database is temporarily unavailable"}
+
+	default:
+		ctl.handleInternalError(r, "Database access error", err)
+		statusCode = http.StatusInternalServerError
+		body = errorBody{Code: codeInternalError, Message: "Database access error"}
+	}
+
+	var constraintErr *repo.ConstraintError
+
+	if errors.As(err, &constraintErr) && constraintErr.Field != "" {
+		body.Details = []errorDetail{{Field: constraintErr.Field, Message: constraintErr.Message}}
+	}
+
+	ctl.sendError(w, r, statusCode, body)
+}
+
+func (ctl *controller) sendError(w http.ResponseWriter, r *http.Request,
+	statusCode int, body errorBody) {
+	body.RequestID = requestID(r)
+	data, err := json.Marshal(errorResponse{Error: body})
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal error to JSON", err)
+		http.Error(w, body.Message, statusCode)
+
+		return
+	}
+
+	ctl.log(r).Info("Sent error message to the client",
+		"status", statusCode, "code", body.Code, "message", body.Message)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, err = w.Write(data)
+	ctl.handleInternalError(r, "Couldn't write data to the HTTP network stream", err)
+}
+
+func (ctl *controller) sendSuccess(w http.ResponseWriter, text string) {
+	data, err := json.Marshal(message{Message: text})
+
+	if err != nil {
+		ctl.logger.Error("Couldn't marshal data to JSON", "error", err)
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+// sendCreated replies to the client with the representation
+// of the created resource and its location.
+func (ctl *controller) sendCreated(w http.ResponseWriter, location string, data []byte) {
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusCreated)
+	ctl.sendData(w, data)
 }
 
 func (ctl *controller) sendData(w http.ResponseWriter, data []byte) {
-	ctl.logger.Println("Sending data to the client:", string(data))
-	_, err := w.Write(data)
-	ctl.handleInternalError("Couldn't write data to the HTTP network stream", err)
+	if _, err := w.Write(data); err != nil {
+		ctl.logger.Warn("Couldn't write data to the HTTP network stream", "error", err)
+	}
 }
@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"restApp/auth"
+	"restApp/logging"
 	"restApp/repo"
 	"strconv"
 
@@ -23,17 +24,16 @@ func (ctl *CustomerController) getCustomer(w http.ResponseWriter, r *http.Reques
 	id, err := strconv.Atoi(params["id"])
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["id"]))
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
 
 		return
 	}
 
-	customer, err := ctl.customerRepo.GetCustomerByID(int64(id))
+	customer, err := ctl.customerRepo.GetCustomerByID(r.Context(), int64(id))
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
+		ctl.handleRepoError(w, r, err,
 			fmt.Sprintf("There is no customer with id %d in the database", id))
 
 		return
@@ -42,8 +42,9 @@ func (ctl *CustomerController) getCustomer(w http.ResponseWriter, r *http.Reques
 	data, err := json.Marshal(customer)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't marshal data to JSON", err)
-		ctl.handleWebError(w, http.StatusInternalServerError, "Couldn't marshal data to JSON")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
@@ -52,21 +53,50 @@ func (ctl *CustomerController) getCustomer(w http.ResponseWriter, r *http.Reques
 }
 
 func (ctl *CustomerController) getCustomers(w http.ResponseWriter, r *http.Request) {
-	customers, err := ctl.customerRepo.GetAllCustomers()
+	query := r.URL.Query()
+	params, err := parseListParams(query)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter := &repo.CustomerFilter{
+		Name:        query.Get("name"),
+		Address:     query.Get("address"),
+		TaxID:       query.Get("tax_id"),
+		Email:       query.Get("email"),
+		PhoneNumber: query.Get("phone_number"),
+	}
+
+	customers, total, err := ctl.customerRepo.GetAllCustomers(r.Context(), filter, params)
+
+	if paramErr := listParamError(err); paramErr != nil {
+		ctl.handleParamError(w, r, paramErr)
+
+		return
+	}
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
 			"Couldn't extract any entry from the customers database")
 
 		return
 	}
 
-	data, err := json.Marshal(customers)
+	var lastID int64
+
+	if len(customers) > 0 {
+		lastID = customers[len(customers)-1].ID
+	}
+
+	data, err := json.Marshal(newCollection(customers, total, params, lastID, len(customers)))
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't marshal data to JSON", err)
-		ctl.handleWebError(w, http.StatusInternalServerError, "Couldn't marshal data to JSON")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
@@ -78,7 +108,7 @@ func (ctl *CustomerController) addCustomer(w http.ResponseWriter, r *http.Reques
 	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
 			"Couldn't read body")
 
 		return
@@ -88,30 +118,56 @@ func (ctl *CustomerController) addCustomer(w http.ResponseWriter, r *http.Reques
 	err = json.Unmarshal(data, customer)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
 			"Couldn't parse JSON data")
 
 		return
 	}
 
-	err = ctl.customerRepo.AddCustomer(customer)
+	err = customer.Validate()
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't add customer to the database", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't add data to the database")
+		ctl.handleValidationError(w, r, err)
 
 		return
 	}
 
-	ctl.sendSuccess(w, "Added successfully")
+	err = ctl.customerRepo.AddCustomer(r.Context(), customer)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The customer doesn't exist")
+
+		return
+	}
+
+	data, err = json.Marshal(customer)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendCreated(w, resourceLocation(r, customer.ID), data)
 }
 
-func (ctl *CustomerController) updateCustomer(w http.ResponseWriter, r *http.Request) {
+func (ctl *CustomerController) replaceCustomer(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
 	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
 			"Couldn't read body")
 
 		return
@@ -121,63 +177,104 @@ func (ctl *CustomerController) updateCustomer(w http.ResponseWriter, r *http.Req
 	err = json.Unmarshal(data, customer)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
 			"Couldn't parse JSON data")
 
 		return
 	}
 
-	// Check if exists.
-	_, err = ctl.customerRepo.GetCustomerByID(customer.ID)
+	// The ID is taken from the URL rather than from the body.
+	customer.ID = int64(id)
+	ctl.updateCustomer(w, r, customer)
+}
+
+func (ctl *CustomerController) patchCustomer(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound, "The customer doesn't exist")
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
 
 		return
 	}
 
-	err = ctl.customerRepo.UpdateCustomer(customer)
+	current, err := ctl.customerRepo.GetCustomerByID(r.Context(), int64(id))
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't update customer in the database", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't update data in the database")
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no customer with id %d in the database", id))
+
+		return
+	}
 
+	customer := new(repo.Customer)
+
+	if !ctl.patchEntity(w, r, data, current, customer) {
 		return
 	}
 
-	ctl.sendSuccess(w, "Updated successfully")
+	customer.ID = current.ID
+	ctl.updateCustomer(w, r, customer)
 }
 
-func (ctl *CustomerController) deleteCustomer(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id, err := strconv.Atoi(params["id"])
+// updateCustomer validates the customer and stores it in the database
+// replacing the previous state.
+func (ctl *CustomerController) updateCustomer(w http.ResponseWriter, r *http.Request,
+	customer *repo.Customer) {
+	err := customer.Validate()
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["id"]))
+		ctl.handleValidationError(w, r, err)
 
 		return
 	}
 
-	// Check if exists.
-	_, err = ctl.customerRepo.GetCustomerByID(int64(id))
+	err = ctl.customerRepo.UpdateCustomer(r.Context(), customer)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			"The customer doesn't exist")
+		ctl.handleRepoError(w, r, err, "The customer doesn't exist")
 
 		return
 	}
 
-	err = ctl.customerRepo.DeleteCustomer(int64(id))
+	data, err := json.Marshal(customer)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't delete the customer", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't delete the customer from the database")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *CustomerController) deleteCustomer(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	err = ctl.customerRepo.DeleteCustomer(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The customer doesn't exist")
 
 		return
 	}
@@ -185,19 +282,30 @@ func (ctl *CustomerController) deleteCustomer(w http.ResponseWriter, r *http.Req
 	ctl.sendSuccess(w, "Deleted successfully")
 }
 
+// customerPolicy lists the roles allowed to use the routes of the controller besides the admins.
+// The customers see only their own entry because the repository is limited to it.
+var customerPolicy = Policy{
+	"getCustomer":     {auth.RoleSales, auth.RoleCustomer},
+	"getCustomers":    {auth.RoleSales, auth.RoleCustomer},
+	"addCustomer":     {auth.RoleSales},
+	"replaceCustomer": {auth.RoleSales},
+	"patchCustomer":   {auth.RoleSales},
+}
+
 // SetupRoutes sets up routes for the controller.
 func (ctl *CustomerController) SetupRoutes(router *mux.Router) {
-	router.Use(jsonMiddleware)
-
-	router.HandleFunc("/{id:[0-9]+}", ctl.getCustomer).Methods("GET")
-	router.HandleFunc("/", ctl.getCustomers).Methods("GET")
-	router.HandleFunc("/", ctl.addCustomer).Methods("POST")
-	router.HandleFunc("/", ctl.updateCustomer).Methods("PATCH")
-	router.HandleFunc("/{id:[0-9]+}", ctl.deleteCustomer).Methods("DELETE")
+	router.Use(jsonMiddleware, ctl.authorize(customerPolicy))
+
+	router.HandleFunc("/{id:[0-9]+}", ctl.getCustomer).Methods("GET").Name("getCustomer")
+	router.HandleFunc("/", ctl.getCustomers).Methods("GET").Name("getCustomers")
+	router.HandleFunc("/", ctl.addCustomer).Methods("POST").Name("addCustomer")
+	router.HandleFunc("/{id:[0-9]+}", ctl.replaceCustomer).Methods("PUT").Name("replaceCustomer")
+	router.HandleFunc("/{id:[0-9]+}", ctl.patchCustomer).Methods("PATCH").Name("patchCustomer")
+	router.HandleFunc("/{id:[0-9]+}", ctl.deleteCustomer).Methods("DELETE").Name("deleteCustomer")
 }
 
 // NewCustomerController returns a new controller for the REST API operations on customers.
-func NewCustomerController(repository repo.ICustomerRepository, logger *log.Logger) *CustomerController {
+func NewCustomerController(repository repo.ICustomerRepository, logger *logging.Logger) *CustomerController {
 	ctl := new(CustomerController)
 
 	ctl.customerRepo = repository
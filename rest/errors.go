@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"net/http"
+	"restApp/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// Machine-readable error codes sent to the client.
+const (
+	codeBadRequest           = "bad_request"
+	codeInvalidJSON          = "invalid_json"
+	codeInvalidPatch         = "invalid_patch"
+	codePatchTestFailed      = "patch_test_failed"
+	codeInvalidParameter     = "invalid_parameter"
+	codeValidationFailed     = "validation_failed"
+	codeNotFound             = "not_found"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeAlreadyExists        = "already_exists"
+	codeInvalidReference     = "invalid_reference"
+	codeReferenced           = "referenced"
+	codeInvalidData          = "invalid_data"
+	codeNoExchangeRate       = "no_exchange_rate"
+	codeInvalidStatus        = "invalid_status"
+	codeConflict             = "conflict"
+	codeUnavailable          = "service_unavailable"
+	codeTimeout              = "timeout"
+	codeInternalError        = "internal_error"
+)
+
+// errorDetail describes a problem with a single field of the request.
+type errorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// errorBody is the description of the error sent to the client.
+type errorBody struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Details   []errorDetail `json:"details,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// errorResponse is the envelope every error is sent to the client in.
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+// paramError is returned when a request parameter has an incorrect value.
+type paramError struct {
+	field   string
+	message string
+}
+
+func (err *paramError) Error() string {
+	return err.message
+}
+
+// SetupErrorHandlers makes the router reply with JSON errors
+// on requests to unknown routes and with unsupported methods.
+func SetupErrorHandlers(router *mux.Router, logger *logging.Logger) {
+	ctl := &controller{logger: logger}
+
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctl.handleWebError(w, r, http.StatusNotFound, codeNotFound,
+			"There is no resource at the specified path")
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctl.handleWebError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed,
+			"The method is not allowed for the resource")
+	})
+}
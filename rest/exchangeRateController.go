@@ -0,0 +1,325 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"restApp/auth"
+	"restApp/logging"
+	"restApp/repo"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ExchangeRateController provides REST API methods for exchange rates.
+// The rates convert the order totals to the currencies requested by the clients.
+type ExchangeRateController struct {
+	exchangeRateRepo repo.IExchangeRateRepository
+	controller
+}
+
+func (ctl *ExchangeRateController) getExchangeRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	rate, err := ctl.exchangeRateRepo.GetExchangeRateByID(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no exchange rate with id %d in the database", id))
+
+		return
+	}
+
+	data, err := json.Marshal(rate)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *ExchangeRateController) getExchangeRates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params, err := parseListParams(query)
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter := &repo.ExchangeRateFilter{
+		BaseCurrency:  query.Get("base_currency"),
+		QuoteCurrency: query.Get("quote_currency"),
+	}
+	filter.DateFrom, err = parseDateParam(query, "date_from")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter.DateTo, err = parseDateParam(query, "date_to")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	rates, total, err := ctl.exchangeRateRepo.GetAllExchangeRates(r.Context(), filter, params)
+
+	if paramErr := listParamError(err); paramErr != nil {
+		ctl.handleParamError(w, r, paramErr)
+
+		return
+	}
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			"Couldn't extract any entry from the exchange rates database")
+
+		return
+	}
+
+	var lastID int64
+
+	if len(rates) > 0 {
+		lastID = rates[len(rates)-1].ID
+	}
+
+	data, err := json.Marshal(newCollection(rates, total, params, lastID, len(rates)))
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *ExchangeRateController) addExchangeRate(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	rate := new(repo.ExchangeRate)
+	err = json.Unmarshal(data, rate)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
+			"Couldn't parse JSON data")
+
+		return
+	}
+
+	err = rate.Validate()
+
+	if err != nil {
+		ctl.handleValidationError(w, r, err)
+
+		return
+	}
+
+	err = ctl.exchangeRateRepo.AddExchangeRate(r.Context(), rate)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The exchange rate doesn't exist")
+
+		return
+	}
+
+	data, err = json.Marshal(rate)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendCreated(w, resourceLocation(r, rate.ID), data)
+}
+
+func (ctl *ExchangeRateController) replaceExchangeRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	rate := new(repo.ExchangeRate)
+	err = json.Unmarshal(data, rate)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
+			"Couldn't parse JSON data")
+
+		return
+	}
+
+	// The ID is taken from the URL rather than from the body.
+	rate.ID = int64(id)
+	ctl.updateExchangeRate(w, r, rate)
+}
+
+func (ctl *ExchangeRateController) patchExchangeRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	current, err := ctl.exchangeRateRepo.GetExchangeRateByID(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no exchange rate with id %d in the database", id))
+
+		return
+	}
+
+	rate := new(repo.ExchangeRate)
+
+	if !ctl.patchEntity(w, r, data, current, rate) {
+		return
+	}
+
+	rate.ID = current.ID
+	ctl.updateExchangeRate(w, r, rate)
+}
+
+// updateExchangeRate validates the exchange rate and stores it in the database
+// replacing the previous state.
+func (ctl *ExchangeRateController) updateExchangeRate(w http.ResponseWriter, r *http.Request,
+	rate *repo.ExchangeRate) {
+	err := rate.Validate()
+
+	if err != nil {
+		ctl.handleValidationError(w, r, err)
+
+		return
+	}
+
+	err = ctl.exchangeRateRepo.UpdateExchangeRate(r.Context(), rate)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The exchange rate doesn't exist")
+
+		return
+	}
+
+	data, err := json.Marshal(rate)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *ExchangeRateController) deleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	err = ctl.exchangeRateRepo.DeleteExchangeRate(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The exchange rate doesn't exist")
+
+		return
+	}
+
+	ctl.sendSuccess(w, "Deleted successfully")
+}
+
+// exchangeRatePolicy lists the roles allowed to use the routes of the controller besides the admins.
+// Only the admins may change the exchange rates.
+var exchangeRatePolicy = Policy{
+	"getExchangeRate":  {auth.RoleSales},
+	"getExchangeRates": {auth.RoleSales},
+}
+
+// SetupRoutes sets up routes for the controller.
+func (ctl *ExchangeRateController) SetupRoutes(router *mux.Router) {
+	router.Use(jsonMiddleware, ctl.authorize(exchangeRatePolicy))
+
+	router.HandleFunc("/{id:[0-9]+}", ctl.getExchangeRate).Methods("GET").Name("getExchangeRate")
+	router.HandleFunc("/", ctl.getExchangeRates).Methods("GET").Name("getExchangeRates")
+	router.HandleFunc("/", ctl.addExchangeRate).Methods("POST").Name("addExchangeRate")
+	router.HandleFunc("/{id:[0-9]+}", ctl.replaceExchangeRate).Methods("PUT").Name("replaceExchangeRate")
+	router.HandleFunc("/{id:[0-9]+}", ctl.patchExchangeRate).Methods("PATCH").Name("patchExchangeRate")
+	router.HandleFunc("/{id:[0-9]+}", ctl.deleteExchangeRate).Methods("DELETE").Name("deleteExchangeRate")
+}
+
+// NewExchangeRateController returns a new controller for the REST API operations on exchange rates.
+func NewExchangeRateController(repository repo.IExchangeRateRepository, logger *logging.Logger) *ExchangeRateController {
+	ctl := new(ExchangeRateController)
+
+	ctl.exchangeRateRepo = repository
+	ctl.logger = logger
+
+	return ctl
+}
@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"restApp/logging"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ReadinessCheck returns an error if a dependency of the application isn't ready.
+type ReadinessCheck func(ctx context.Context) error
+
+// namedCheck is the readiness check with the name it's reported under.
+type namedCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// healthStatus is the state of the application sent to the client.
+type healthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// HealthController provides the liveness and readiness endpoints for the orchestrators.
+type HealthController struct {
+	checks  []namedCheck
+	timeout time.Duration
+	controller
+}
+
+// AddCheck adds the check to the readiness endpoint.
+func (ctl *HealthController) AddCheck(name string, check ReadinessCheck) {
+	ctl.checks = append(ctl.checks, namedCheck{name, check})
+}
+
+// getLiveness replies if the process is able to serve the requests at all.
+func (ctl *HealthController) getLiveness(w http.ResponseWriter, r *http.Request) {
+	ctl.sendStatus(w, r, http.StatusOK, healthStatus{Status: "alive"})
+}
+
+// getReadiness replies if all the dependencies of the application are ready.
+func (ctl *HealthController) getReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ctl.timeout)
+	defer cancel()
+
+	status := healthStatus{Status: "ready", Checks: make(map[string]string)}
+	statusCode := http.StatusOK
+
+	for _, check := range ctl.checks {
+		if err := check.check(ctx); err != nil {
+			// The endpoint isn't authenticated, so the details of the error
+			// such as the hosts and the schema are only logged.
+			ctl.log(r).Warn("Readiness check failed", "check", check.name, "error", err)
+			status.Checks[check.name] = "failed"
+			status.Status = "not ready"
+			statusCode = http.StatusServiceUnavailable
+		} else {
+			status.Checks[check.name] = "ok"
+		}
+	}
+
+	ctl.sendStatus(w, r, statusCode, status)
+}
+
+func (ctl *HealthController) sendStatus(w http.ResponseWriter, r *http.Request,
+	statusCode int, status healthStatus) {
+	data, err := json.Marshal(status)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	// The state must never be cached by proxies.
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	ctl.sendData(w, data)
+}
+
+// SetupRoutes sets up routes for the controller.
+func (ctl *HealthController) SetupRoutes(router *mux.Router) {
+	router.Use(jsonMiddleware)
+
+	router.HandleFunc("/healthz", ctl.getLiveness).Methods("GET", "HEAD")
+	router.HandleFunc("/readyz", ctl.getReadiness).Methods("GET", "HEAD")
+}
+
+// NewHealthController returns a new controller for the health endpoints.
+// The readiness checks must finish within the specified timeout.
+func NewHealthController(timeout time.Duration, logger *logging.Logger) *HealthController {
+	ctl := new(HealthController)
+
+	ctl.timeout = timeout
+	ctl.logger = logger
+
+	return ctl
+}
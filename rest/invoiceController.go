@@ -0,0 +1,338 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"restApp/auth"
+	"restApp/logging"
+	"restApp/render"
+	"restApp/repo"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// InvoiceController provides REST API methods for invoices.
+// The invoices are issued for the orders and never change afterwards.
+type InvoiceController struct {
+	invoiceRepo repo.IInvoiceRepository
+	issuer      repo.Party
+	paymentDays int
+	controller
+}
+
+func (ctl *InvoiceController) getInvoice(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := ctl.invoice(w, r)
+
+	if !ok {
+		return
+	}
+
+	data, err := json.Marshal(invoice)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *InvoiceController) getInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	ctl.sendDocument(w, r, "application/pdf", "pdf", render.InvoicePDF)
+}
+
+func (ctl *InvoiceController) getInvoiceHTML(w http.ResponseWriter, r *http.Request) {
+	ctl.sendDocument(w, r, "text/html; charset=utf-8", "html", render.InvoiceHTML)
+}
+
+// sendDocument renders the invoice and replies to the client with the document.
+// The document is rendered completely before it's sent, so a failure is still reported as JSON.
+func (ctl *InvoiceController) sendDocument(w http.ResponseWriter, r *http.Request,
+	contentType, extension string, renderer func(io.Writer, *repo.Invoice) error) {
+	invoice, ok := ctl.invoice(w, r)
+
+	if !ok {
+		return
+	}
+
+	var document bytes.Buffer
+	err := renderer(&document, invoice)
+
+	if errors.Is(err, render.ErrUnsupportedText) {
+		ctl.handleWebError(w, r, http.StatusUnprocessableEntity, codeInvalidData,
+			fmt.Sprintf("Couldn't render the invoice: %v", err))
+
+		return
+	}
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't render the invoice", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't render the invoice")
+
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`inline; filename="invoice-%06d.%s"`, invoice.Number, extension))
+	ctl.sendData(w, document.Bytes())
+}
+
+// invoice reads the invoice under the ID from the URL.
+// It replies to the client with the error and returns false if there is no such invoice.
+func (ctl *InvoiceController) invoice(w http.ResponseWriter, r *http.Request) (*repo.Invoice, bool) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return nil, false
+	}
+
+	invoice, err := ctl.invoiceRepo.GetInvoiceByID(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no invoice with id %d in the database", id))
+
+		return nil, false
+	}
+
+	return invoice, true
+}
+
+func (ctl *InvoiceController) getInvoices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params, err := parseListParams(query)
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter := new(repo.InvoiceFilter)
+	filter.CustomerID, err = parseIntParam(query, "customer_id")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter.OrderID, err = parseIntParam(query, "order_id")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter.DateFrom, err = parseDateParam(query, "date_from")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter.DateTo, err = parseDateParam(query, "date_to")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	invoices, total, err := ctl.invoiceRepo.GetAllInvoices(r.Context(), filter, params)
+
+	if paramErr := listParamError(err); paramErr != nil {
+		ctl.handleParamError(w, r, paramErr)
+
+		return
+	}
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			"Couldn't extract any entry from the invoices database")
+
+		return
+	}
+
+	var lastID int64
+
+	if len(invoices) > 0 {
+		lastID = invoices[len(invoices)-1].ID
+	}
+
+	data, err := json.Marshal(newCollection(invoices, total, params, lastID, len(invoices)))
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+// invoiceRequest is the body of the request issuing the invoice.
+// The due date is a date without the time like the date filters of the invoices.
+type invoiceRequest struct {
+	DueDate      string `json:"due_date"`
+	PaymentTerms string `json:"payment_terms"`
+}
+
+// addInvoice issues the invoice for the order. The body may specify the due date
+// and the payment terms, the rest of the invoice is taken from the order.
+func (ctl *InvoiceController) addInvoice(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	orderID, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	request := new(invoiceRequest)
+
+	// The body is optional.
+	if len(bytes.TrimSpace(data)) > 0 {
+		err = json.Unmarshal(data, request)
+
+		if err != nil {
+			ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
+				"Couldn't parse JSON data")
+
+			return
+		}
+	}
+
+	// The dates are parsed in UTC like the date filters, so they compare as dates.
+	year, month, day := time.Now().UTC().Date()
+	terms := &repo.Invoice{
+		OrderID:      int64(orderID),
+		Issuer:       ctl.issuer,
+		IssueDate:    time.Date(year, month, day, 0, 0, 0, 0, time.UTC),
+		PaymentTerms: request.PaymentTerms,
+	}
+
+	if request.DueDate == "" {
+		terms.DueDate = terms.IssueDate.AddDate(0, 0, ctl.paymentDays)
+	} else {
+		terms.DueDate, err = time.Parse(dateLayout, request.DueDate)
+
+		if err != nil {
+			ctl.handleValidationError(w, r, &repo.ValidationError{Fields: []repo.FieldError{
+				{Field: "due_date", Message: "must be a date in the YYYY-MM-DD format"}}})
+
+			return
+		}
+	}
+
+	if terms.DueDate.Before(terms.IssueDate) {
+		ctl.handleValidationError(w, r, &repo.ValidationError{Fields: []repo.FieldError{
+			{Field: "due_date", Message: "must not be before the issue date"}}})
+
+		return
+	}
+
+	if terms.PaymentTerms == "" {
+		days := terms.DueDate.Sub(terms.IssueDate).Hours() / 24
+		terms.PaymentTerms = fmt.Sprintf("Payment within %d days of the invoice date", int(days))
+	}
+
+	err = terms.Validate()
+
+	if err != nil {
+		ctl.handleValidationError(w, r, err)
+
+		return
+	}
+
+	err = ctl.invoiceRepo.IssueInvoice(r.Context(), terms)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The order doesn't exist")
+
+		return
+	}
+
+	data, err = json.Marshal(terms)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	// The invoice is served by the routes of the controller rather than the order ones.
+	ctl.sendCreated(w, fmt.Sprintf("/invoices/%d", terms.ID), data)
+}
+
+// invoicePolicy lists the roles allowed to use the routes of the controller besides the admins.
+// The customers see only their own invoices because the repository is limited to them.
+// The invoices are issued on the order routes, so orderPolicy covers the issuing.
+var invoicePolicy = Policy{
+	"getInvoice":     {auth.RoleSales, auth.RoleCustomer},
+	"getInvoices":    {auth.RoleSales, auth.RoleCustomer},
+	"getInvoicePDF":  {auth.RoleSales, auth.RoleCustomer},
+	"getInvoiceHTML": {auth.RoleSales, auth.RoleCustomer},
+}
+
+// SetupRoutes sets up routes for the controller.
+func (ctl *InvoiceController) SetupRoutes(router *mux.Router) {
+	router.Use(jsonMiddleware, ctl.authorize(invoicePolicy))
+
+	router.HandleFunc("/{id:[0-9]+}", ctl.getInvoice).Methods("GET").Name("getInvoice")
+	router.HandleFunc("/", ctl.getInvoices).Methods("GET").Name("getInvoices")
+	router.HandleFunc("/{id:[0-9]+}/pdf", ctl.getInvoicePDF).Methods("GET").Name("getInvoicePDF")
+	router.HandleFunc("/{id:[0-9]+}/html", ctl.getInvoiceHTML).Methods("GET").Name("getInvoiceHTML")
+}
+
+// SetupOrderRoutes sets up the route issuing the invoices on the router of the orders.
+func (ctl *InvoiceController) SetupOrderRoutes(router *mux.Router) {
+	router.HandleFunc("/{id:[0-9]+}/invoice", ctl.addInvoice).Methods("POST").Name("addInvoice")
+}
+
+// NewInvoiceController returns a new controller for the REST API operations on invoices.
+// The invoices are issued by the issuer and due in the number of payment days by default.
+func NewInvoiceController(repository repo.IInvoiceRepository, issuer repo.Party,
+	paymentDays int, logger *logging.Logger) *InvoiceController {
+	ctl := new(InvoiceController)
+
+	ctl.invoiceRepo = repository
+	ctl.issuer = issuer
+	ctl.paymentDays = paymentDays
+	ctl.logger = logger
+
+	return ctl
+}
@@ -1,6 +1,27 @@
 package rest
 
-import "net/http"
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"restApp/auth"
+	"restApp/logging"
+	"restApp/repo"
+	"time"
+)
+
+// requestIDHeader is the header carrying the ID of the request.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of the request ID accepted from the client.
+const maxRequestIDLength = 128
+
+type contextKey int
+
+const requestIDKey contextKey = iota
 
 func jsonMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
@@ -8,3 +29,194 @@ func jsonMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(wr, req)
 	})
 }
+
+// RequestIDMiddleware assigns an ID to every request. The ID is taken
+// from the X-Request-ID header of the request or generated if the header is absent,
+// and sent back to the client in the same header.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(requestIDHeader)
+
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		wr.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(req.Context(), requestIDKey, id)
+		next.ServeHTTP(wr, req.WithContext(ctx))
+	})
+}
+
+// IAuthenticator authenticates the requests to the REST API.
+type IAuthenticator interface {
+	Authenticate(r *http.Request) (*auth.Principal, error)
+}
+
+// AuthMiddleware rejects the requests that aren't authenticated
+// and attaches the principal to the context of the authenticated ones.
+// The repositories are limited to the customer of the principal, if any.
+func AuthMiddleware(authenticator IAuthenticator, logger *logging.Logger) func(http.Handler) http.Handler {
+	ctl := &controller{logger: logger}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+			principal, err := authenticator.Authenticate(req)
+
+			switch {
+			case errors.Is(err, auth.ErrNoCredentials):
+				wr.Header().Set("WWW-Authenticate", `Bearer realm="restApp"`)
+				ctl.handleWebError(wr, req, http.StatusUnauthorized, codeUnauthorized,
+					"Authentication is required")
+
+				return
+
+			case errors.Is(err, auth.ErrInvalidCredentials):
+				ctl.log(req).Info("Authentication failed", "error", err)
+				wr.Header().Set("WWW-Authenticate", `Bearer realm="restApp", error="invalid_token"`)
+				ctl.handleWebError(wr, req, http.StatusUnauthorized, codeUnauthorized,
+					"The credentials are invalid")
+
+				return
+
+			case err != nil:
+				ctl.handleRepoError(wr, req, err, "")
+
+				return
+			}
+
+			// The repositories are limited only when the customer is known,
+			// so a customer without one would see the data of every customer.
+			if principal.CustomerID == 0 && principal.HasRole(auth.RoleCustomer) {
+				ctl.log(req).Warn("The customer isn't limited to a customer ID", "subject", principal.Subject)
+				ctl.handleWebError(wr, req, http.StatusForbidden, codeForbidden,
+					"The credentials aren't limited to a customer")
+
+				return
+			}
+
+			ctx := auth.NewContext(req.Context(), principal)
+
+			if principal.CustomerID != 0 {
+				ctx = repo.WithCustomerScope(ctx, principal.CustomerID)
+			}
+
+			next.ServeHTTP(wr, req.WithContext(ctx))
+		})
+	}
+}
+
+// TimeoutMiddleware limits the time the database queries of every request may take.
+// The queries still running when the timeout expires are canceled.
+// Zero timeout means no limit.
+func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(wr, req.WithContext(ctx))
+		})
+	}
+}
+
+// AccessLogMiddleware writes an entry about every request handled.
+// The query of the URL isn't logged because the filters may contain personal data.
+func AccessLogMiddleware(logger *logging.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			recorder := &responseRecorder{ResponseWriter: wr, status: http.StatusOK}
+			next.ServeHTTP(recorder, req)
+
+			logger.Info("Request handled",
+				"request_id", requestID(req),
+				"method", req.Method,
+				"path", req.URL.Path,
+				"status", recorder.status,
+				"bytes", recorder.size,
+				"duration_ms", float64(time.Since(start).Microseconds())/1000,
+				"remote_addr", req.RemoteAddr,
+				"user_agent", req.UserAgent())
+		})
+	}
+}
+
+// BodyLogMiddleware logs the bodies of the responses sent to the clients.
+// It's meant for debugging, so the personal data in the bodies is redacted.
+func BodyLogMiddleware(logger *logging.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+			var body bytes.Buffer
+			recorder := &responseRecorder{ResponseWriter: wr, status: http.StatusOK, body: &body}
+			next.ServeHTTP(recorder, req)
+
+			logger.Debug("Sent response",
+				"request_id", requestID(req),
+				"method", req.Method,
+				"path", req.URL.Path,
+				"status", recorder.status,
+				"body", string(logging.RedactJSON(body.Bytes())))
+		})
+	}
+}
+
+// responseRecorder is the response writer remembering the status and the size
+// of the response and keeping a copy of the body if the buffer is specified.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+	body   *bytes.Buffer
+}
+
+func (recorder *responseRecorder) WriteHeader(statusCode int) {
+	recorder.status = statusCode
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	if recorder.body != nil {
+		recorder.body.Write(data)
+	}
+
+	n, err := recorder.ResponseWriter.Write(data)
+	recorder.size += n
+
+	return n, err
+}
+
+// requestID returns the ID assigned to the request by RequestIDMiddleware.
+func requestID(req *http.Request) string {
+	id, _ := req.Context().Value(requestIDKey).(string)
+	return id
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(buf)
+}
+
+// validRequestID checks if the ID received from the client
+// is safe to be written to the logs and the headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"restApp/auth"
+	"restApp/logging"
+	"restApp/repo"
+	"testing"
+)
+
+// fakeAuthenticator returns the same principal or error for every request.
+type fakeAuthenticator struct {
+	principal *auth.Principal
+	err       error
+}
+
+func (authenticator *fakeAuthenticator) Authenticate(r *http.Request) (*auth.Principal, error) {
+	return authenticator.principal, authenticator.err
+}
+
+// checkErrorCode checks the machine-readable code of the error response.
+func checkErrorCode(t *testing.T, rec *httptest.ResponseRecorder, code string) {
+	t.Helper()
+	var response errorResponse
+
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("the response isn't an error: %s", rec.Body)
+	}
+
+	if response.Error.Code != code {
+		t.Errorf("error code = %q, want %q", response.Error.Code, code)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator *fakeAuthenticator
+		status        int
+		code          string
+		// scope is the customer the repositories are limited to, zero means none.
+		scope int64
+	}{
+		{"no credentials", &fakeAuthenticator{err: auth.ErrNoCredentials},
+			http.StatusUnauthorized, codeUnauthorized, 0},
+		{"invalid credentials", &fakeAuthenticator{err: auth.ErrInvalidCredentials},
+			http.StatusUnauthorized, codeUnauthorized, 0},
+		{"unavailable", &fakeAuthenticator{err: repo.ErrUnavailable},
+			http.StatusServiceUnavailable, codeUnavailable, 0},
+		{"admin", &fakeAuthenticator{principal: &auth.Principal{Subject: "a", Roles: []string{auth.RoleAdmin}}},
+			http.StatusNoContent, "", 0},
+		{"sales", &fakeAuthenticator{principal: &auth.Principal{Subject: "s", Roles: []string{auth.RoleSales}}},
+			http.StatusNoContent, "", 0},
+		{"customer", &fakeAuthenticator{principal: &auth.Principal{
+			Subject: "c", Roles: []string{auth.RoleCustomer}, CustomerID: 42}},
+			http.StatusNoContent, "", 42},
+		{"customer without customer ID", &fakeAuthenticator{principal: &auth.Principal{
+			Subject: "c", Roles: []string{auth.RoleCustomer}}},
+			http.StatusForbidden, codeForbidden, 0},
+		{"customer among other roles without customer ID", &fakeAuthenticator{principal: &auth.Principal{
+			Subject: "c", Roles: []string{auth.RoleSales, auth.RoleCustomer}}},
+			http.StatusForbidden, codeForbidden, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				called    bool
+				principal *auth.Principal
+				scope     int64
+				scoped    bool
+			)
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				principal, _ = auth.FromContext(r.Context())
+				scope, scoped = repo.CustomerScope(r.Context())
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			logger := logging.New(io.Discard, logging.LevelError)
+			handler := AuthMiddleware(test.authenticator, logger)(next)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/customers/", nil))
+
+			if rec.Code != test.status {
+				t.Fatalf("status = %d, want %d", rec.Code, test.status)
+			}
+
+			if test.code != "" {
+				if called {
+					t.Error("the rejected request reached the handler")
+				}
+
+				checkErrorCode(t, rec, test.code)
+
+				if test.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+					t.Error("WWW-Authenticate header is missing")
+				}
+
+				return
+			}
+
+			if principal != test.authenticator.principal {
+				t.Errorf("principal = %+v, want %+v", principal, test.authenticator.principal)
+			}
+
+			if scoped != (test.scope != 0) || scope != test.scope {
+				t.Errorf("customer scope = %d, %v, want %d", scope, scoped, test.scope)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareError(t *testing.T) {
+	// The errors of the authenticator other than the known ones aren't the client's fault.
+	authenticator := &fakeAuthenticator{err: errors.New("connection reset")}
+	handler := AuthMiddleware(authenticator, logging.New(io.Discard, logging.LevelError))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("the request reached the handler")
+		}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/customers/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
@@ -4,18 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"restApp/auth"
+	"restApp/logging"
 	"restApp/repo"
 	"strconv"
 
 	"github.com/gorilla/mux"
 )
 
-// OrderController provides REST API methods for orders and their services.
+// OrderController provides REST API methods for orders and their line items.
 type OrderController struct {
-	orderRepo   repo.IOrderRepository
-	serviceRepo repo.IServiceRepository
+	orderRepo repo.IOrderRepository
+	uow       repo.IUnitOfWork
 	controller
 }
 
@@ -24,27 +25,47 @@ func (ctl *OrderController) getOrder(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(params["id"])
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["id"]))
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
 
 		return
 	}
 
-	order, err := ctl.orderRepo.GetOrderByID(int64(id))
+	currency := r.URL.Query().Get("currency")
+
+	if currency != "" && !repo.IsCurrency(currency) {
+		ctl.handleParamError(w, r, &paramError{"currency", fmt.Sprintf(
+			"Incorrect parameter for currency: %v, must be an ISO 4217 currency code", currency)})
+
+		return
+	}
+
+	order, err := ctl.orderRepo.GetOrderByID(r.Context(), int64(id))
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
+		ctl.handleRepoError(w, r, err,
 			fmt.Sprintf("There is no order with id %d in the database", id))
 
 		return
 	}
 
+	if currency != "" {
+		order.Totals, err = ctl.orderRepo.GetOrderTotals(r.Context(), int64(id), currency)
+
+		if err != nil {
+			ctl.handleRepoError(w, r, err,
+				fmt.Sprintf("There is no order with id %d in the database", id))
+
+			return
+		}
+	}
+
 	data, err := json.Marshal(order)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't marshal data to JSON", err)
-		ctl.handleWebError(w, http.StatusInternalServerError, "Couldn't marshal data to JSON")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
@@ -53,21 +74,76 @@ func (ctl *OrderController) getOrder(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ctl *OrderController) getOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := ctl.orderRepo.GetAllOrders()
+	query := r.URL.Query()
+	params, err := parseListParams(query)
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter := new(repo.OrderFilter)
+	filter.CustomerID, err = parseIntParam(query, "customer_id")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter.DateFrom, err = parseDateParam(query, "date_from")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter.DateTo, err = parseDateParam(query, "date_to")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter.Status = query.Get("status")
+
+	if filter.Status != "" && !repo.IsOrderStatus(filter.Status) {
+		ctl.handleParamError(w, r, &paramError{"status",
+			fmt.Sprintf("Incorrect parameter for status: %v", filter.Status)})
+
+		return
+	}
+
+	orders, total, err := ctl.orderRepo.GetAllOrders(r.Context(), filter, params)
+
+	if paramErr := listParamError(err); paramErr != nil {
+		ctl.handleParamError(w, r, paramErr)
+
+		return
+	}
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
+		ctl.handleRepoError(w, r, err,
 			"Couldn't extract any entry from the orders database")
 
 		return
 	}
 
-	data, err := json.Marshal(orders)
+	var lastID int64
+
+	if len(orders) > 0 {
+		lastID = orders[len(orders)-1].ID
+	}
+
+	data, err := json.Marshal(newCollection(orders, total, params, lastID, len(orders)))
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't marshal data to JSON", err)
-		ctl.handleWebError(w, http.StatusInternalServerError, "Couldn't marshal data to JSON")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
@@ -79,40 +155,79 @@ func (ctl *OrderController) addOrder(w http.ResponseWriter, r *http.Request) {
 	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
 			"Couldn't read body")
 
 		return
 	}
 
-	order := new(repo.Order)
+	order := new(repo.OrderWithServices)
 	err = json.Unmarshal(data, order)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
 			"Couldn't parse JSON data")
 
 		return
 	}
 
-	err = ctl.orderRepo.AddOrder(order)
+	if order.Services == nil {
+		order.Services = make([]*repo.LineItem, 0)
+	}
+
+	// A single unit of the service is ordered if the quantity is omitted.
+	for _, item := range order.Services {
+		if item != nil && item.Quantity == 0 {
+			item.Quantity = 1
+		}
+	}
+
+	err = order.Validate()
+
+	if err != nil {
+		ctl.handleValidationError(w, r, err)
+
+		return
+	}
+
+	err = ctl.uow.WithTx(r.Context(), func(repos *repo.Repos) error {
+		return repos.Orders.CreateOrder(r.Context(), order)
+	})
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The order doesn't exist")
+
+		return
+	}
+
+	data, err = json.Marshal(order)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't add service to the database", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't add data to the database")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
 
-	ctl.sendSuccess(w, "Added successfully")
+	ctl.sendCreated(w, resourceLocation(r, order.ID), data)
 }
 
-func (ctl *OrderController) updateOrder(w http.ResponseWriter, r *http.Request) {
+func (ctl *OrderController) replaceOrder(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
 	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
 			"Couldn't read body")
 
 		return
@@ -122,105 +237,135 @@ func (ctl *OrderController) updateOrder(w http.ResponseWriter, r *http.Request)
 	err = json.Unmarshal(data, order)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
 			"Couldn't parse JSON data")
 
 		return
 	}
 
-	// Check if exists.
-	_, err = ctl.orderRepo.GetOrderByID(order.ID)
+	// The ID is taken from the URL rather than from the body.
+	order.ID = int64(id)
+	ctl.updateOrder(w, r, order)
+}
+
+func (ctl *OrderController) patchOrder(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound, "The order doesn't exist")
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
 
 		return
 	}
 
-	err = ctl.orderRepo.UpdateOrder(order)
+	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't update service in the database", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't update data in the database")
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
 
 		return
 	}
 
-	ctl.sendSuccess(w, "Updated successfully")
+	current, err := ctl.orderRepo.GetOrderByID(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no order with id %d in the database", id))
+
+		return
+	}
+
+	order := new(repo.Order)
+
+	if !ctl.patchEntity(w, r, data, current, order) {
+		return
+	}
+
+	order.ID = current.ID
+	ctl.updateOrder(w, r, order)
 }
 
-func (ctl *OrderController) deleteOrder(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id, err := strconv.Atoi(params["id"])
+// updateOrder validates the order and stores it in the database
+// replacing the previous state.
+func (ctl *OrderController) updateOrder(w http.ResponseWriter, r *http.Request,
+	order *repo.Order) {
+	// The totals are computed from the line items, so the ones in the body are dropped.
+	order.Totals = nil
+	err := order.Validate()
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["id"]))
+		ctl.handleValidationError(w, r, err)
 
 		return
 	}
 
-	// Check if exists.
-	_, err = ctl.orderRepo.GetOrderByID(int64(id))
+	err = ctl.orderRepo.UpdateOrder(r.Context(), order)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			"The order doesn't exist")
+		ctl.handleRepoError(w, r, err, "The order doesn't exist")
 
 		return
 	}
 
-	err = ctl.orderRepo.DeleteOrder(int64(id))
+	data, err := json.Marshal(order)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't delete the customer", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't delete the customer from the database")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
 
-	ctl.sendSuccess(w, "Deleted successfully")
+	ctl.sendData(w, data)
 }
 
-func (ctl *OrderController) getOrderService(w http.ResponseWriter, r *http.Request) {
+func (ctl *OrderController) deleteOrder(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	orderID, err := strconv.Atoi(params["orderId"])
+	id, err := strconv.Atoi(params["id"])
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["orderId"]))
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
 
 		return
 	}
 
-	serviceID, err := strconv.Atoi(params["serviceId"])
+	err = ctl.orderRepo.DeleteOrder(r.Context(), int64(id))
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["serviceId"]))
+		ctl.handleRepoError(w, r, err, "The order doesn't exist")
 
 		return
 	}
 
-	service, err := ctl.orderRepo.GetOrderServiceByID(int64(orderID), int64(serviceID))
+	ctl.sendSuccess(w, "Deleted successfully")
+}
+
+func (ctl *OrderController) getOrderService(w http.ResponseWriter, r *http.Request) {
+	orderID, itemID, ok := ctl.lineItemParams(w, r)
+
+	if !ok {
+		return
+	}
+
+	item, err := ctl.orderRepo.GetLineItemByID(r.Context(), orderID, itemID)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			fmt.Sprintf("There is no service with id %d for order with id %d in the database", serviceID, orderID))
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no line item with id %d for order with id %d in the database", itemID, orderID))
 
 		return
 	}
 
-	data, err := json.Marshal(service)
+	data, err := json.Marshal(item)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't marshal data to JSON", err)
-		ctl.handleWebError(w, http.StatusInternalServerError, "Couldn't marshal data to JSON")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
@@ -233,27 +378,27 @@ func (ctl *OrderController) getOrderServices(w http.ResponseWriter, r *http.Requ
 	orderID, err := strconv.Atoi(params["orderId"])
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["orderId"]))
+		ctl.handleParamError(w, r, &paramError{"orderId",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["orderId"])})
 
 		return
 	}
 
-	services, err := ctl.orderRepo.GetAllOrderServices(int64(orderID))
+	items, err := ctl.orderRepo.GetAllLineItems(r.Context(), int64(orderID))
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			fmt.Sprintf("There are no services for order with id %d in the database", orderID))
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There are no line items for order with id %d in the database", orderID))
 
 		return
 	}
 
-	data, err := json.Marshal(services)
+	data, err := json.Marshal(items)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't marshal data to JSON", err)
-		ctl.handleWebError(w, http.StatusInternalServerError, "Couldn't marshal data to JSON")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
@@ -266,104 +411,195 @@ func (ctl *OrderController) addOrderService(w http.ResponseWriter, r *http.Reque
 	orderID, err := strconv.Atoi(params["orderId"])
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["orderId"]))
+		ctl.handleParamError(w, r, &paramError{"orderId",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["orderId"])})
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
 
 		return
 	}
 
-	serviceID, err := strconv.Atoi(params["serviceId"])
+	item := new(repo.LineItem)
+	err = json.Unmarshal(data, item)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["serviceId"]))
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
+			"Couldn't parse JSON data")
 
 		return
 	}
 
-	// Check if the order exists.
-	_, err = ctl.orderRepo.GetOrderByID(int64(orderID))
+	// A single unit of the service is ordered if the quantity is omitted.
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+
+	// The order is taken from the URL rather than from the body.
+	item.OrderID = int64(orderID)
+	err = item.Validate()
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			"The order doesn't exist")
+		ctl.handleValidationError(w, r, err)
 
 		return
 	}
 
-	// Check if the service exists.
-	_, err = ctl.serviceRepo.GetServiceByID(int64(serviceID))
+	// The repository checks the status of the order and inserts the item
+	// in the transaction, so the order can't be confirmed or deleted in between.
+	err = ctl.uow.WithTx(r.Context(), func(repos *repo.Repos) error {
+		return repos.Orders.AddLineItem(r.Context(), item)
+	})
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			"The service doesn't exist")
+		ctl.handleRepoError(w, r, err, "The order doesn't exist")
 
 		return
 	}
 
-	err = ctl.orderRepo.AddServiceToOrder(int64(orderID), int64(serviceID))
+	data, err = json.Marshal(item)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't add service to the database", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't add data to the database")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
 
-	ctl.sendSuccess(w, "Added successfully")
+	ctl.sendCreated(w, resourceLocation(r, item.ID), data)
 }
 
-func (ctl *OrderController) deleteOrderSevice(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	orderID, err := strconv.Atoi(params["orderId"])
+func (ctl *OrderController) replaceOrderService(w http.ResponseWriter, r *http.Request) {
+	orderID, itemID, ok := ctl.lineItemParams(w, r)
+
+	if !ok {
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	current, err := ctl.orderRepo.GetLineItemByID(r.Context(), orderID, itemID)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no line item with id %d for order with id %d in the database", itemID, orderID))
+
+		return
+	}
+
+	item := new(repo.LineItem)
+	err = json.Unmarshal(data, item)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
+			"Couldn't parse JSON data")
+
+		return
+	}
+
+	ctl.updateOrderService(w, r, current, item)
+}
+
+func (ctl *OrderController) patchOrderService(w http.ResponseWriter, r *http.Request) {
+	orderID, itemID, ok := ctl.lineItemParams(w, r)
+
+	if !ok {
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	current, err := ctl.orderRepo.GetLineItemByID(r.Context(), orderID, itemID)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["orderId"]))
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no line item with id %d for order with id %d in the database", itemID, orderID))
+
+		return
+	}
+
+	item := new(repo.LineItem)
 
+	if !ctl.patchEntity(w, r, data, current, item) {
 		return
 	}
 
-	serviceID, err := strconv.Atoi(params["serviceId"])
+	ctl.updateOrderService(w, r, current, item)
+}
+
+// updateOrderService validates the line item and stores its quantity, discount and notes
+// in the database. The service, the unit price, its currency and the tax rate can't be
+// changed, so they are taken from the current state of the item along with the IDs.
+func (ctl *OrderController) updateOrderService(w http.ResponseWriter, r *http.Request,
+	current, item *repo.LineItem) {
+	item.ID = current.ID
+	item.OrderID = current.OrderID
+	item.ServiceID = current.ServiceID
+	item.UnitPrice = current.UnitPrice
+	item.Currency = current.Currency
+	item.TaxRate = current.TaxRate
+
+	err := item.Validate()
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["serviceId"]))
+		ctl.handleValidationError(w, r, err)
 
 		return
 	}
 
-	// Check if the order exists.
-	_, err = ctl.orderRepo.GetOrderByID(int64(orderID))
+	err = ctl.orderRepo.UpdateLineItem(r.Context(), item)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			"The order doesn't exist")
+		ctl.handleRepoError(w, r, err, "The line item doesn't exist")
 
 		return
 	}
 
-	// Check if the service exists in the order.
-	_, err = ctl.orderRepo.GetOrderServiceByID(int64(orderID), int64(serviceID))
+	data, err := json.Marshal(item)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			"The service doesn't exist or isn't included in the order")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
 
-	err = ctl.orderRepo.DeleteServiceFromOrder(int64(orderID), int64(serviceID))
+	ctl.sendData(w, data)
+}
+
+func (ctl *OrderController) deleteOrderService(w http.ResponseWriter, r *http.Request) {
+	orderID, itemID, ok := ctl.lineItemParams(w, r)
+
+	if !ok {
+		return
+	}
+
+	err := ctl.orderRepo.DeleteLineItem(r.Context(), orderID, itemID)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't delete the service from the order", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't delete the service from the order")
+		ctl.handleRepoError(w, r, err, "The line item doesn't exist")
 
 		return
 	}
@@ -371,33 +607,173 @@ func (ctl *OrderController) deleteOrderSevice(w http.ResponseWriter, r *http.Req
 	ctl.sendSuccess(w, "Deleted successfully")
 }
 
+func (ctl *OrderController) transitionOrder(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	// The history records who moved the order, if the request is authenticated.
+	var changedBy string
+
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		changedBy = principal.Subject
+	}
+
+	_, err = ctl.orderRepo.TransitionOrder(r.Context(), int64(id), params["action"], changedBy)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The order doesn't exist")
+
+		return
+	}
+
+	order, err := ctl.orderRepo.GetOrderByID(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no order with id %d in the database", id))
+
+		return
+	}
+
+	data, err := json.Marshal(order)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *OrderController) getOrderHistory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	changes, err := ctl.orderRepo.GetStatusHistory(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no order with id %d in the database", id))
+
+		return
+	}
+
+	data, err := json.Marshal(changes)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+// lineItemParams parses the IDs of the order and its line item from the URL.
+// It replies to the client with the error and returns false if any of them is incorrect.
+func (ctl *OrderController) lineItemParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	params := mux.Vars(r)
+	orderID, err := strconv.Atoi(params["orderId"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"orderId",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["orderId"])})
+
+		return 0, 0, false
+	}
+
+	itemID, err := strconv.Atoi(params["itemId"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"itemId",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["itemId"])})
+
+		return 0, 0, false
+	}
+
+	return int64(orderID), int64(itemID), true
+}
+
+// orderPolicy lists the roles allowed to use the routes of the controller besides the admins.
+// The customers see only their own orders because the repository is limited to them.
+// The addInvoice route is set up by the invoice controller.
+var orderPolicy = Policy{
+	"getOrder":             {auth.RoleSales, auth.RoleCustomer},
+	"getOrders":            {auth.RoleSales, auth.RoleCustomer},
+	"addOrder":             {auth.RoleSales},
+	"addOrderWithoutSlash": {auth.RoleSales},
+	"replaceOrder":         {auth.RoleSales},
+	"patchOrder":           {auth.RoleSales},
+	"deleteOrder":          {auth.RoleSales},
+	"getOrderService":      {auth.RoleSales, auth.RoleCustomer},
+	"getOrderServices":     {auth.RoleSales, auth.RoleCustomer},
+	"addOrderService":      {auth.RoleSales},
+	"replaceOrderService":  {auth.RoleSales},
+	"patchOrderService":    {auth.RoleSales},
+	"deleteOrderService":   {auth.RoleSales},
+	"transitionOrder":      {auth.RoleSales},
+	"getOrderHistory":      {auth.RoleSales, auth.RoleCustomer},
+	"addInvoice":           {auth.RoleSales},
+}
+
 // SetupRoutes sets up routes for the controller.
 func (ctl *OrderController) SetupRoutes(router *mux.Router) {
-	router.Use(jsonMiddleware)
-
-	router.HandleFunc("/{id:[0-9]+}", ctl.getOrder).Methods("GET")
-	router.HandleFunc("/", ctl.getOrders).Methods("GET")
-	router.HandleFunc("/", ctl.addOrder).Methods("POST")
-	router.HandleFunc("/", ctl.updateOrder).Methods("PATCH")
-	router.HandleFunc("/{id:[0-9]+}", ctl.deleteOrder).Methods("DELETE")
-
-	router.HandleFunc("/{orderId:[0-9]+}/services/{serviceId:[0-9]+}",
-		ctl.getOrderService).Methods("GET")
-	router.HandleFunc("/{orderId:[0-9]}/services",
-		ctl.getOrderServices).Methods("GET")
-	router.HandleFunc("/{orderId:[0-9]+}/services/{serviceId:[0-9]+}",
-		ctl.addOrderService).Methods("POST")
-	router.HandleFunc("/{orderId:[0-9]+}/services/{serviceId:[0-9]+}",
-		ctl.deleteOrderSevice).Methods("DELETE")
+	router.Use(jsonMiddleware, ctl.authorize(orderPolicy))
+
+	router.HandleFunc("/{id:[0-9]+}", ctl.getOrder).Methods("GET").Name("getOrder")
+	router.HandleFunc("/", ctl.getOrders).Methods("GET").Name("getOrders")
+	router.HandleFunc("/", ctl.addOrder).Methods("POST").Name("addOrder")
+	// The orders are also created on the path without the trailing slash.
+	router.HandleFunc("", ctl.addOrder).Methods("POST").Name("addOrderWithoutSlash")
+	router.HandleFunc("/{id:[0-9]+}", ctl.replaceOrder).Methods("PUT").Name("replaceOrder")
+	router.HandleFunc("/{id:[0-9]+}", ctl.patchOrder).Methods("PATCH").Name("patchOrder")
+	router.HandleFunc("/{id:[0-9]+}", ctl.deleteOrder).Methods("DELETE").Name("deleteOrder")
+
+	router.HandleFunc("/{orderId:[0-9]+}/services/{itemId:[0-9]+}",
+		ctl.getOrderService).Methods("GET").Name("getOrderService")
+	router.HandleFunc("/{orderId:[0-9]+}/services",
+		ctl.getOrderServices).Methods("GET").Name("getOrderServices")
+	router.HandleFunc("/{orderId:[0-9]+}/services",
+		ctl.addOrderService).Methods("POST").Name("addOrderService")
+	router.HandleFunc("/{orderId:[0-9]+}/services/{itemId:[0-9]+}",
+		ctl.replaceOrderService).Methods("PUT").Name("replaceOrderService")
+	router.HandleFunc("/{orderId:[0-9]+}/services/{itemId:[0-9]+}",
+		ctl.patchOrderService).Methods("PATCH").Name("patchOrderService")
+	router.HandleFunc("/{orderId:[0-9]+}/services/{itemId:[0-9]+}",
+		ctl.deleteOrderService).Methods("DELETE").Name("deleteOrderService")
+
+	router.HandleFunc("/{id:[0-9]+}/{action:confirm|start|complete|cancel}",
+		ctl.transitionOrder).Methods("POST").Name("transitionOrder")
+	router.HandleFunc("/{id:[0-9]+}/history",
+		ctl.getOrderHistory).Methods("GET").Name("getOrderHistory")
 }
 
 // NewOrderController returns a new controller for the REST API operations on orders.
 func NewOrderController(orderRepository repo.IOrderRepository,
-	serviceRepository repo.IServiceRepository, logger *log.Logger) *OrderController {
+	uow repo.IUnitOfWork, logger *logging.Logger) *OrderController {
 	ctl := new(OrderController)
 
 	ctl.orderRepo = orderRepository
-	ctl.serviceRepo = serviceRepository
+	ctl.uow = uow
 	ctl.logger = logger
 
 	return ctl
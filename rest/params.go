@@ -0,0 +1,174 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"restApp/repo"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pagination defaults for the collection endpoints.
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+	dateLayout   = "2006-01-02"
+)
+
+// collection is a single page of entries sent to the client
+// along with the pagination metadata.
+type collection struct {
+	Data      interface{} `json:"data"`
+	Total     int64       `json:"total"`
+	Limit     int         `json:"limit"`
+	Offset    int         `json:"offset"`
+	NextAfter int64       `json:"next_after,omitempty"`
+}
+
+// newCollection creates a page for the collection. The lastID is the ID
+// of the last entry on the page, and the count is the number of entries on the page.
+func newCollection(data interface{}, total int64, params *repo.ListParams,
+	lastID int64, count int) *collection {
+	page := &collection{
+		Data:   data,
+		Total:  total,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}
+
+	// The cursor is offered only if there may be more entries to fetch.
+	if params.Keyset() && count > 0 && count == params.Limit {
+		page.NextAfter = lastID
+	}
+
+	return page
+}
+
+// parseListParams extracts the pagination and sorting parameters from the query string.
+func parseListParams(query url.Values) (*repo.ListParams, error) {
+	params := &repo.ListParams{Limit: defaultLimit}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+
+		if err != nil || limit < 1 || limit > maxLimit {
+			return nil, &paramError{"limit", fmt.Sprintf(
+				"Incorrect parameter for limit: %v, must be between 1 and %d", value, maxLimit)}
+		}
+
+		params.Limit = limit
+	}
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+
+		if err != nil || offset < 0 {
+			return nil, &paramError{"offset", fmt.Sprintf("Incorrect parameter for offset: %v", value)}
+		}
+
+		params.Offset = offset
+	}
+
+	if value := query.Get("after"); value != "" {
+		after, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil || after < 1 {
+			return nil, &paramError{"after", fmt.Sprintf("Incorrect parameter for after: %v", value)}
+		}
+
+		params.After = after
+	}
+
+	if value := query.Get("sort"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			sortField := repo.SortField{Field: field}
+
+			if strings.HasPrefix(field, "-") {
+				sortField.Field = field[1:]
+				sortField.Desc = true
+			}
+
+			if sortField.Field == "" {
+				return nil, &paramError{"sort", fmt.Sprintf("Incorrect parameter for sort: %v", value)}
+			}
+
+			params.Sort = append(params.Sort, sortField)
+		}
+	}
+
+	if params.After != 0 && params.Offset != 0 {
+		return nil, &paramError{"after", "Parameters after and offset can't be used together"}
+	}
+
+	return params, nil
+}
+
+// listParamError converts the error of the collection query
+// caused by the incorrect list parameters into the parameter error.
+// It returns nil if the error isn't caused by the list parameters.
+func listParamError(err error) error {
+	switch {
+	case errors.Is(err, repo.ErrInvalidSortField):
+		return &paramError{"sort", err.Error()}
+
+	case errors.Is(err, repo.ErrInvalidCursor):
+		return &paramError{"after", err.Error()}
+	}
+
+	return nil
+}
+
+// parseIntParam extracts an optional integer parameter from the query string.
+func parseIntParam(query url.Values, name string) (int64, error) {
+	value := query.Get(name)
+
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		return 0, &paramError{name, fmt.Sprintf("Incorrect parameter for %s: %v", name, value)}
+	}
+
+	return number, nil
+}
+
+// parseMoneyParam extracts an optional amount of money from the query string.
+func parseMoneyParam(query url.Values, name string) (*repo.Money, error) {
+	value := query.Get(name)
+
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := repo.ParseMoney(value)
+
+	if err != nil {
+		return nil, &paramError{name, fmt.Sprintf("Incorrect parameter for %s: %v, %s", name, value, err)}
+	}
+
+	return &amount, nil
+}
+
+// parseDateParam extracts an optional date parameter in the YYYY-MM-DD format from the query string.
+func parseDateParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(dateLayout, value)
+
+	if err != nil {
+		return nil, &paramError{name, fmt.Sprintf(
+			"Incorrect parameter for %s: %v, must be YYYY-MM-DD", name, value)}
+	}
+
+	return &date, nil
+}
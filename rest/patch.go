@@ -0,0 +1,487 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Media types of the patch documents accepted by the PATCH methods.
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+var (
+	// errUnsupportedPatch is returned when the patch document is of an unknown media type.
+	errUnsupportedPatch = errors.New("unsupported patch media type")
+	// errPatchTestFailed is returned when the "test" operation of the JSON Patch fails.
+	errPatchTestFailed = errors.New("patch test operation failed")
+)
+
+// patchOperation is a single operation of the JSON Patch document (RFC 6902).
+type patchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// applyPatch applies the patch of the specified media type to the JSON document.
+// JSON Merge Patch (RFC 7396) is assumed if the media type is
+// application/merge-patch+json, application/json or empty.
+func applyPatch(contentType string, document, patch []byte) ([]byte, error) {
+	mediaType := mergePatchType
+
+	if contentType != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(contentType)
+
+		if err != nil {
+			return nil, errUnsupportedPatch
+		}
+	}
+
+	target, err := decodeJSON(document)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch mediaType {
+	case mergePatchType, "application/json":
+		changes, err := decodeJSON(patch)
+
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse the merge patch: %w", err)
+		}
+
+		target = mergePatch(target, changes)
+
+	case jsonPatchType:
+		var operations []patchOperation
+
+		if err = json.Unmarshal(patch, &operations); err != nil {
+			return nil, fmt.Errorf("couldn't parse the JSON patch: %w", err)
+		}
+
+		target, err = jsonPatch(target, operations)
+
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, errUnsupportedPatch
+	}
+
+	return json.Marshal(target)
+}
+
+// decodeJSON decodes the JSON document preserving the precision of the numbers.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// mergePatch applies the merge patch to the target as described in RFC 7396.
+func mergePatch(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+
+	if !ok {
+		object = make(map[string]interface{})
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = mergePatch(object[name], value)
+		}
+	}
+
+	return object
+}
+
+// jsonPatch applies the operations to the document as described in RFC 6902.
+func jsonPatch(document interface{}, operations []patchOperation) (interface{}, error) {
+	var err error
+
+	for i, operation := range operations {
+		if operation.Path == nil {
+			return nil, fmt.Errorf("operation %d has no path", i)
+		}
+
+		path := *operation.Path
+
+		switch operation.Op {
+		case "add", "replace", "test":
+			if operation.Value == nil {
+				return nil, fmt.Errorf("operation %d has no value", i)
+			}
+
+			var value interface{}
+			value, err = decodeJSON(*operation.Value)
+
+			if err != nil {
+				return nil, fmt.Errorf("operation %d has an incorrect value: %w", i, err)
+			}
+
+			switch operation.Op {
+			case "add":
+				document, err = pointerAdd(document, path, value)
+
+			case "replace":
+				document, err = pointerReplace(document, path, value)
+
+			case "test":
+				var current interface{}
+				current, err = pointerGet(document, path)
+
+				if err == nil && !jsonEqual(current, value) {
+					err = errPatchTestFailed
+				}
+			}
+
+		case "remove":
+			document, _, err = pointerRemove(document, path)
+
+		case "move", "copy":
+			if operation.From == nil {
+				return nil, fmt.Errorf("operation %d has no from", i)
+			}
+
+			var value interface{}
+
+			if operation.Op == "move" {
+				if strings.HasPrefix(path, *operation.From+"/") {
+					return nil, fmt.Errorf("operation %d moves a value into itself", i)
+				}
+
+				document, value, err = pointerRemove(document, *operation.From)
+			} else {
+				value, err = pointerGet(document, *operation.From)
+
+				// The copy mustn't share the nested objects and arrays with the source,
+				// or the later operations on either path would change both.
+				if err == nil {
+					value, err = deepCopy(value)
+				}
+			}
+
+			if err == nil {
+				document, err = pointerAdd(document, path, value)
+			}
+
+		default:
+			return nil, fmt.Errorf("operation %d is unknown: %q", i, operation.Op)
+		}
+
+		if errors.Is(err, errPatchTestFailed) {
+			return nil, err
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("operation %d failed: %w", i, err)
+		}
+	}
+
+	return document, nil
+}
+
+// jsonEqual checks if the decoded JSON values are equal.
+// The numbers are compared by their values rather than by their representation.
+func jsonEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+
+		if !ok {
+			return false
+		}
+
+		if x == y {
+			return true
+		}
+
+		xf, errX := x.Float64()
+		yf, errY := y.Float64()
+
+		return errX == nil && errY == nil && xf == yf
+
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for name, value := range x {
+			other, ok := y[name]
+
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+
+		return true
+
+	case []interface{}:
+		y, ok := b.([]interface{})
+
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return a == b
+}
+
+// deepCopy returns a copy of the decoded JSON value sharing nothing with the value.
+func deepCopy(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJSON(data)
+}
+
+// parsePointer splits the JSON Pointer (RFC 6901) into the unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("incorrect JSON pointer %q", pointer)
+	}
+
+	unescaper := strings.NewReplacer("~1", "/", "~0", "~")
+	tokens := strings.Split(pointer[1:], "/")
+
+	for i := range tokens {
+		tokens[i] = unescaper.Replace(tokens[i])
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex converts the reference token into the index of the array of the specified length.
+// The "-" token and the index equal to the length are allowed only for appending.
+func arrayIndex(token string, length int, appending bool) (int, error) {
+	if token == "-" && appending {
+		return length, nil
+	}
+
+	index, err := strconv.Atoi(token)
+
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("incorrect array index %q", token)
+	}
+
+	if index > length || index == length && !appending {
+		return 0, fmt.Errorf("array index %d is out of range", index)
+	}
+
+	return index, nil
+}
+
+// pointerGet returns the value the pointer refers to.
+func pointerGet(document interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	current := document
+
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+
+			if !ok {
+				return nil, fmt.Errorf("member %q doesn't exist", token)
+			}
+
+			current = value
+
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), false)
+
+			if err != nil {
+				return nil, err
+			}
+
+			current = node[index]
+
+		default:
+			return nil, fmt.Errorf("path %q doesn't exist", pointer)
+		}
+	}
+
+	return current, nil
+}
+
+// pointerUpdate replaces the container the pointer refers to the member of
+// with the result of the function applied to it and the last reference token.
+func pointerUpdate(document interface{}, pointer string,
+	update func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return update(nil, "")
+	}
+
+	var walk func(node interface{}, tokens []string) (interface{}, error)
+	walk = func(node interface{}, tokens []string) (interface{}, error) {
+		if len(tokens) == 1 {
+			return update(node, tokens[0])
+		}
+
+		child, err := pointerGet(node, "/"+escapeToken(tokens[0]))
+
+		if err != nil {
+			return nil, err
+		}
+
+		child, err = walk(child, tokens[1:])
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch container := node.(type) {
+		case map[string]interface{}:
+			container[tokens[0]] = child
+
+		case []interface{}:
+			index, _ := arrayIndex(tokens[0], len(container), false)
+			container[index] = child
+		}
+
+		return node, nil
+	}
+
+	return walk(document, tokens)
+}
+
+// pointerAdd adds the value at the location the pointer refers to.
+func pointerAdd(document interface{}, pointer string, value interface{}) (interface{}, error) {
+	return pointerUpdate(document, pointer, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case nil:
+			return value, nil
+
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), true)
+
+			if err != nil {
+				return nil, err
+			}
+
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+
+			return container, nil
+		}
+
+		return nil, fmt.Errorf("path %q doesn't exist", pointer)
+	})
+}
+
+// pointerReplace replaces the value the pointer refers to.
+func pointerReplace(document interface{}, pointer string, value interface{}) (interface{}, error) {
+	if _, err := pointerGet(document, pointer); err != nil {
+		return nil, err
+	}
+
+	return pointerUpdate(document, pointer, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case nil:
+			return value, nil
+
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+
+		case []interface{}:
+			index, _ := arrayIndex(token, len(container), false)
+			container[index] = value
+
+			return container, nil
+		}
+
+		return nil, fmt.Errorf("path %q doesn't exist", pointer)
+	})
+}
+
+// pointerRemove removes the value the pointer refers to and returns it.
+func pointerRemove(document interface{}, pointer string) (interface{}, interface{}, error) {
+	removed, err := pointerGet(document, pointer)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	document, err = pointerUpdate(document, pointer, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case nil:
+			return nil, nil
+
+		case map[string]interface{}:
+			delete(container, token)
+			return container, nil
+
+		case []interface{}:
+			index, _ := arrayIndex(token, len(container), false)
+			return append(container[:index], container[index+1:]...), nil
+		}
+
+		return nil, fmt.Errorf("path %q doesn't exist", pointer)
+	})
+
+	return document, removed, err
+}
+
+func escapeToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
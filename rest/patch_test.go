@@ -0,0 +1,166 @@
+package rest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// errAny stands for any error in the tests that don't check the particular one.
+var errAny = errors.New("any error")
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		document    string
+		patch       string
+		want        string
+		err         error
+	}{
+		// JSON Merge Patch
+		{"merge replace", "", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`, nil},
+		{"merge add", "application/json", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`, nil},
+		{"merge remove", mergePatchType, `{"a":"b"}`, `{"a":null}`, `{}`, nil},
+		{"merge nested", mergePatchType, `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`, nil},
+		{"merge array", mergePatchType, `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`, nil},
+		{"merge non-object", mergePatchType, `{"a":"b"}`, `["c"]`, `["c"]`, nil},
+		{"merge into scalar", mergePatchType, `{"a":1}`, `{"a":{"b":null}}`, `{"a":{}}`, nil},
+		{"merge keeps numbers", mergePatchType, `{"price":1.10}`, `{"name":"x"}`, `{"name":"x","price":1.10}`, nil},
+		{"merge with charset", mergePatchType + "; charset=utf-8", `{}`, `{"a":1}`, `{"a":1}`, nil},
+		{"merge malformed", mergePatchType, `{}`, `{"a":`, "", errAny},
+
+		// JSON Patch
+		{"add member", jsonPatchType, `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{"add element", jsonPatchType, `{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"append element", jsonPatchType, `{"foo":["bar"]}`,
+			`[{"op":"add","path":"/foo/-","value":"qux"}]`, `{"foo":["bar","qux"]}`, nil},
+		{"add root", jsonPatchType, `{"foo":"bar"}`,
+			`[{"op":"add","path":"","value":{"x":1}}]`, `{"x":1}`, nil},
+		{"add to missing parent", jsonPatchType, `{}`,
+			`[{"op":"add","path":"/a/b","value":1}]`, "", errAny},
+		{"add past the end", jsonPatchType, `{"foo":[1]}`,
+			`[{"op":"add","path":"/foo/2","value":2}]`, "", errAny},
+		{"remove member", jsonPatchType, `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"remove element", jsonPatchType, `{"foo":["bar","qux","baz"]}`,
+			`[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"remove missing", jsonPatchType, `{}`,
+			`[{"op":"remove","path":"/a"}]`, "", errAny},
+		{"replace", jsonPatchType, `{"baz":"qux"}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo"}`, nil},
+		{"replace missing", jsonPatchType, `{}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`, "", errAny},
+		{"replace appended", jsonPatchType, `{"foo":[1]}`,
+			`[{"op":"replace","path":"/foo/-","value":2}]`, "", errAny},
+		{"move", jsonPatchType, `{"foo":{"waldo":"fred"},"qux":{}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{},"qux":{"thud":"fred"}}`, nil},
+		{"move element", jsonPatchType, `{"foo":["a","b","c","d"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["a","c","d","b"]}`, nil},
+		{"move into itself", jsonPatchType, `{"a":{"b":1}}`,
+			`[{"op":"move","from":"/a","path":"/a/b/c"}]`, "", errAny},
+		{"copy is independent", jsonPatchType, `{"a":{"b":1}}`,
+			`[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			`{"a":{"b":1},"c":{"b":2}}`, nil},
+		{"copy without from", jsonPatchType, `{"a":1}`,
+			`[{"op":"copy","path":"/c"}]`, "", errAny},
+		{"test numbers by value", jsonPatchType, `{"a":1}`,
+			`[{"op":"test","path":"/a","value":1.0},{"op":"replace","path":"/a","value":2}]`, `{"a":2}`, nil},
+		{"test objects", jsonPatchType, `{"a":{"b":[1,"x",null]}}`,
+			`[{"op":"test","path":"/a","value":{"b":[1,"x",null]}}]`, `{"a":{"b":[1,"x",null]}}`, nil},
+		{"test failed", jsonPatchType, `{"a":1}`,
+			`[{"op":"test","path":"/a","value":"1"}]`, "", errPatchTestFailed},
+		{"escaped pointer", jsonPatchType, `{"a/b":1,"m~n":2}`,
+			`[{"op":"replace","path":"/a~1b","value":3},{"op":"replace","path":"/m~0n","value":4}]`,
+			`{"a/b":3,"m~n":4}`, nil},
+		{"leading zero index", jsonPatchType, `{"foo":[1,2]}`,
+			`[{"op":"replace","path":"/foo/01","value":3}]`, "", errAny},
+		{"pointer without slash", jsonPatchType, `{"a":1}`,
+			`[{"op":"remove","path":"a"}]`, "", errAny},
+		{"unknown operation", jsonPatchType, `{}`,
+			`[{"op":"merge","path":"/a"}]`, "", errAny},
+		{"no path", jsonPatchType, `{}`,
+			`[{"op":"add","value":1}]`, "", errAny},
+		{"no value", jsonPatchType, `{}`,
+			`[{"op":"add","path":"/a"}]`, "", errAny},
+		{"not an array", jsonPatchType, `{}`,
+			`{"op":"add","path":"/a","value":1}`, "", errAny},
+
+		{"unsupported media type", "text/plain", `{}`, `{}`, "", errUnsupportedPatch},
+		{"malformed media type", ";;", `{}`, `{}`, "", errUnsupportedPatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := applyPatch(test.contentType, []byte(test.document), []byte(test.patch))
+
+			if test.err != nil {
+				if err == nil || test.err != errAny && !errors.Is(err, test.err) {
+					t.Fatalf("error = %v, want %v", err, test.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !jsonEqual(decode(t, string(got)), decode(t, test.want)) {
+				t.Errorf("result = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+		err     bool
+	}{
+		{"", nil, false},
+		{"/", []string{""}, false},
+		{"/a/0", []string{"a", "0"}, false},
+		{"/a~1b/m~0n", []string{"a/b", "m~n"}, false},
+		// ~01 is an escaped ~ followed by 1 rather than an escaped /.
+		{"/~01", []string{"~1"}, false},
+		{"a", nil, true},
+	}
+
+	for _, test := range tests {
+		got, err := parsePointer(test.pointer)
+
+		if (err != nil) != test.err {
+			t.Errorf("parsePointer(%q) error = %v, want error %v", test.pointer, err, test.err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parsePointer(%q) = %q, want %q", test.pointer, got, test.want)
+		}
+	}
+}
+
+func TestEscapeToken(t *testing.T) {
+	for _, token := range []string{"a", "a/b", "m~n", "~1", "/~/"} {
+		tokens, err := parsePointer("/" + escapeToken(token))
+
+		if err != nil || len(tokens) != 1 || tokens[0] != token {
+			t.Errorf("escaping %q doesn't survive parsing: %q, %v", token, tokens, err)
+		}
+	}
+}
+
+func decode(t *testing.T, data string) interface{} {
+	t.Helper()
+	value, err := decodeJSON([]byte(data))
+
+	if err != nil {
+		t.Fatalf("couldn't decode %s: %v", data, err)
+	}
+
+	return value
+}
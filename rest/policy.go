@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"net/http"
+	"restApp/auth"
+
+	"github.com/gorilla/mux"
+)
+
+// Policy maps the names of the routes to the roles allowed to use them.
+// The admins may use all the routes, so the routes missing from the policy
+// are allowed only to them.
+type Policy map[string][]string
+
+// allows checks if the policy allows the principal to use the route with the specified name.
+func (policy Policy) allows(principal *auth.Principal, route string) bool {
+	if principal.HasRole(auth.RoleAdmin) {
+		return true
+	}
+
+	for _, role := range policy[route] {
+		if principal.HasRole(role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authorize rejects the requests the policy doesn't allow to the principal.
+// The requests without a principal are let through because they can reach
+// the controller only if the authentication is disabled.
+func (ctl *controller) authorize(policy Policy) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+			principal, ok := auth.FromContext(req.Context())
+
+			if !ok {
+				next.ServeHTTP(wr, req)
+
+				return
+			}
+
+			route := ""
+
+			if current := mux.CurrentRoute(req); current != nil {
+				route = current.GetName()
+			}
+
+			if !policy.allows(principal, route) {
+				ctl.log(req).Info("Access denied", "subject", principal.Subject, "route", route)
+				ctl.handleWebError(wr, req, http.StatusForbidden, codeForbidden,
+					"The operation isn't allowed")
+
+				return
+			}
+
+			next.ServeHTTP(wr, req)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"restApp/auth"
+	"restApp/logging"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+var testPolicy = Policy{
+	"getItems": {auth.RoleSales, auth.RoleCustomer},
+	"addItem":  {auth.RoleSales},
+}
+
+func TestPolicyAllows(t *testing.T) {
+	admin := &auth.Principal{Roles: []string{auth.RoleAdmin}}
+	sales := &auth.Principal{Roles: []string{auth.RoleSales}}
+	customer := &auth.Principal{Roles: []string{auth.RoleCustomer}, CustomerID: 1}
+	several := &auth.Principal{Roles: []string{"auditor", auth.RoleSales}}
+	none := &auth.Principal{}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		route     string
+		want      bool
+	}{
+		{"admin on a listed route", admin, "addItem", true},
+		{"admin on an unlisted route", admin, "deleteItem", true},
+		{"admin on an unnamed route", admin, "", true},
+		{"sales", sales, "addItem", true},
+		{"customer reading", customer, "getItems", true},
+		{"customer changing", customer, "addItem", false},
+		{"sales on an unlisted route", sales, "deleteItem", false},
+		{"customer on an unlisted route", customer, "deleteItem", false},
+		{"unnamed route", sales, "", false},
+		{"any of the roles", several, "addItem", true},
+		{"unknown role", &auth.Principal{Roles: []string{"auditor"}}, "getItems", false},
+		{"no roles", none, "getItems", false},
+	}
+
+	for _, test := range tests {
+		if got := testPolicy.allows(test.principal, test.route); got != test.want {
+			t.Errorf("%s: allows(%v, %q) = %v, want %v", test.name, test.principal.Roles, test.route, got, test.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	ctl := &controller{logger: logging.New(io.Discard, logging.LevelError)}
+	router := mux.NewRouter()
+	router.Use(ctl.authorize(testPolicy))
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	router.HandleFunc("/items", ok).Methods("GET").Name("getItems")
+	router.HandleFunc("/items", ok).Methods("POST").Name("addItem")
+	router.HandleFunc("/items/{id}", ok).Methods("DELETE").Name("deleteItem")
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		path      string
+		want      int
+	}{
+		{"admin", &auth.Principal{Roles: []string{auth.RoleAdmin}}, "DELETE", "/items/1", http.StatusNoContent},
+		{"allowed", &auth.Principal{Roles: []string{auth.RoleSales}}, "POST", "/items", http.StatusNoContent},
+		{"denied", &auth.Principal{Roles: []string{auth.RoleCustomer}, CustomerID: 1}, "POST", "/items",
+			http.StatusForbidden},
+		{"admin-only", &auth.Principal{Roles: []string{auth.RoleSales}}, "DELETE", "/items/1", http.StatusForbidden},
+		// The authentication is disabled.
+		{"no principal", nil, "DELETE", "/items/1", http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+
+		if test.principal != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), test.principal))
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != test.want {
+			t.Errorf("%s: %s %s = %d, want %d", test.name, test.method, test.path, rec.Code, test.want)
+		}
+
+		if test.want == http.StatusForbidden {
+			checkErrorCode(t, rec, codeForbidden)
+		}
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"restApp/auth"
+	"restApp/logging"
 	"restApp/repo"
 	"strconv"
 
@@ -23,17 +24,16 @@ func (ctl *ServiceController) getService(w http.ResponseWriter, r *http.Request)
 	id, err := strconv.Atoi(params["id"])
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["id"]))
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
 
 		return
 	}
 
-	service, err := ctl.serviceRepo.GetServiceByID(int64(id))
+	service, err := ctl.serviceRepo.GetServiceByID(r.Context(), int64(id))
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
+		ctl.handleRepoError(w, r, err,
 			fmt.Sprintf("There is no service with id %d in the database", id))
 
 		return
@@ -42,8 +42,9 @@ func (ctl *ServiceController) getService(w http.ResponseWriter, r *http.Request)
 	data, err := json.Marshal(service)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't marshal data to JSON", err)
-		ctl.handleWebError(w, http.StatusInternalServerError, "Couldn't marshal data to JSON")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
@@ -52,21 +53,63 @@ func (ctl *ServiceController) getService(w http.ResponseWriter, r *http.Request)
 }
 
 func (ctl *ServiceController) getServices(w http.ResponseWriter, r *http.Request) {
-	services, err := ctl.serviceRepo.GetAllServices()
+	query := r.URL.Query()
+	params, err := parseListParams(query)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter := &repo.ServiceFilter{
+		Title:    query.Get("title"),
+		Category: query.Get("category"),
+		Currency: query.Get("currency"),
+	}
+	filter.PriceMin, err = parseMoneyParam(query, "price_min")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter.PriceMax, err = parseMoneyParam(query, "price_max")
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	services, total, err := ctl.serviceRepo.GetAllServices(r.Context(), filter, params)
+
+	if paramErr := listParamError(err); paramErr != nil {
+		ctl.handleParamError(w, r, paramErr)
+
+		return
+	}
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
 			"Couldn't extract any entry from the services database")
 
 		return
 	}
 
-	data, err := json.Marshal(services)
+	var lastID int64
+
+	if len(services) > 0 {
+		lastID = services[len(services)-1].ID
+	}
+
+	data, err := json.Marshal(newCollection(services, total, params, lastID, len(services)))
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't marshal data to JSON", err)
-		ctl.handleWebError(w, http.StatusInternalServerError, "Couldn't marshal data to JSON")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
@@ -78,7 +121,7 @@ func (ctl *ServiceController) addService(w http.ResponseWriter, r *http.Request)
 	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
 			"Couldn't read body")
 
 		return
@@ -88,30 +131,56 @@ func (ctl *ServiceController) addService(w http.ResponseWriter, r *http.Request)
 	err = json.Unmarshal(data, service)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
 			"Couldn't parse JSON data")
 
 		return
 	}
 
-	err = ctl.serviceRepo.AddService(service)
+	err = service.Validate()
+
+	if err != nil {
+		ctl.handleValidationError(w, r, err)
+
+		return
+	}
+
+	err = ctl.serviceRepo.AddService(r.Context(), service)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The service doesn't exist")
+
+		return
+	}
+
+	data, err = json.Marshal(service)
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't add service to the database", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't add data to the database")
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
 
 		return
 	}
 
-	ctl.sendSuccess(w, "Added successfully")
+	ctl.sendCreated(w, resourceLocation(r, service.ID), data)
 }
 
-func (ctl *ServiceController) updateService(w http.ResponseWriter, r *http.Request) {
+func (ctl *ServiceController) replaceService(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
 	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
 			"Couldn't read body")
 
 		return
@@ -121,63 +190,104 @@ func (ctl *ServiceController) updateService(w http.ResponseWriter, r *http.Reque
 	err = json.Unmarshal(data, service)
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
 			"Couldn't parse JSON data")
 
 		return
 	}
 
-	// Check if exists.
-	_, err = ctl.serviceRepo.GetServiceByID(service.ID)
+	// The ID is taken from the URL rather than from the body.
+	service.ID = int64(id)
+	ctl.updateService(w, r, service)
+}
+
+func (ctl *ServiceController) patchService(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound, "The service doesn't exist")
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
 
 		return
 	}
 
-	err = ctl.serviceRepo.UpdateService(service)
+	current, err := ctl.serviceRepo.GetServiceByID(r.Context(), int64(id))
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't update service in the database", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't update data in the database")
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no service with id %d in the database", id))
 
 		return
 	}
 
-	ctl.sendSuccess(w, "Updated successfully")
+	service := new(repo.Service)
+
+	if !ctl.patchEntity(w, r, data, current, service) {
+		return
+	}
+
+	service.ID = current.ID
+	ctl.updateService(w, r, service)
 }
 
-func (ctl *ServiceController) deleteService(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id, err := strconv.Atoi(params["id"])
+// updateService validates the service and stores it in the database
+// replacing the previous state.
+func (ctl *ServiceController) updateService(w http.ResponseWriter, r *http.Request,
+	service *repo.Service) {
+	err := service.Validate()
 
 	if err != nil {
-		ctl.handleWebError(w, http.StatusBadRequest,
-			fmt.Sprintf("Incorrect parameter for id: %v", params["id"]))
+		ctl.handleValidationError(w, r, err)
 
 		return
 	}
 
-	// Check if exists.
-	_, err = ctl.serviceRepo.GetServiceByID(int64(id))
+	err = ctl.serviceRepo.UpdateService(r.Context(), service)
 
 	if err != nil {
-		ctl.handleInternalError("Database access error", err)
-		ctl.handleWebError(w, http.StatusNotFound,
-			"The service doesn't exist")
+		ctl.handleRepoError(w, r, err, "The service doesn't exist")
 
 		return
 	}
 
-	err = ctl.serviceRepo.DeleteService(int64(id))
+	data, err := json.Marshal(service)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *ServiceController) deleteService(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
 
 	if err != nil {
-		ctl.handleInternalError("Couldn't delete the service", err)
-		ctl.handleWebError(w, http.StatusInternalServerError,
-			"Couldn't delete the service from the database")
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	err = ctl.serviceRepo.DeleteService(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The service doesn't exist")
 
 		return
 	}
@@ -185,19 +295,27 @@ func (ctl *ServiceController) deleteService(w http.ResponseWriter, r *http.Reque
 	ctl.sendSuccess(w, "Deleted successfully")
 }
 
+// servicePolicy lists the roles allowed to use the routes of the controller besides the admins.
+// Only the admins may change the services and their prices.
+var servicePolicy = Policy{
+	"getService":  {auth.RoleSales, auth.RoleCustomer},
+	"getServices": {auth.RoleSales, auth.RoleCustomer},
+}
+
 // SetupRoutes sets up routes for the controller.
 func (ctl *ServiceController) SetupRoutes(router *mux.Router) {
-	router.Use(jsonMiddleware)
-
-	router.HandleFunc("/{id:[0-9]+}", ctl.getService).Methods("GET")
-	router.HandleFunc("/", ctl.getServices).Methods("GET")
-	router.HandleFunc("/", ctl.addService).Methods("POST")
-	router.HandleFunc("/", ctl.updateService).Methods("PATCH")
-	router.HandleFunc("/services/{id:[0-9]+}", ctl.deleteService).Methods("DELETE")
+	router.Use(jsonMiddleware, ctl.authorize(servicePolicy))
+
+	router.HandleFunc("/{id:[0-9]+}", ctl.getService).Methods("GET").Name("getService")
+	router.HandleFunc("/", ctl.getServices).Methods("GET").Name("getServices")
+	router.HandleFunc("/", ctl.addService).Methods("POST").Name("addService")
+	router.HandleFunc("/{id:[0-9]+}", ctl.replaceService).Methods("PUT").Name("replaceService")
+	router.HandleFunc("/{id:[0-9]+}", ctl.patchService).Methods("PATCH").Name("patchService")
+	router.HandleFunc("/{id:[0-9]+}", ctl.deleteService).Methods("DELETE").Name("deleteService")
 }
 
 // NewServiceController returns a new controller for the REST API operations on services.
-func NewServiceController(repository repo.IServiceRepository, logger *log.Logger) *ServiceController {
+func NewServiceController(repository repo.IServiceRepository, logger *logging.Logger) *ServiceController {
 	ctl := new(ServiceController)
 
 	ctl.serviceRepo = repository
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"restApp/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// IDBStats provides the statistics of the database connection pool.
+type IDBStats interface {
+	Stats() sql.DBStats
+}
+
+// dbStats is the representation of the connection pool statistics sent to the client.
+type dbStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMs     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// SystemController provides REST API methods for the state of the application.
+type SystemController struct {
+	db IDBStats
+	controller
+}
+
+func (ctl *SystemController) getDBStats(w http.ResponseWriter, r *http.Request) {
+	stats := ctl.db.Stats()
+	data, err := json.Marshal(dbStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	})
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+// systemPolicy lists the roles allowed to use the routes of the controller besides the admins.
+var systemPolicy = Policy{}
+
+// SetupRoutes sets up routes for the controller.
+func (ctl *SystemController) SetupRoutes(router *mux.Router) {
+	router.Use(jsonMiddleware, ctl.authorize(systemPolicy))
+
+	router.HandleFunc("/db/stats", ctl.getDBStats).Methods("GET").Name("getDBStats")
+}
+
+// NewSystemController returns a new controller for the REST API operations
+// on the state of the application.
+func NewSystemController(db IDBStats, logger *logging.Logger) *SystemController {
+	ctl := new(SystemController)
+
+	ctl.db = db
+	ctl.logger = logger
+
+	return ctl
+}
@@ -0,0 +1,310 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"restApp/auth"
+	"restApp/logging"
+	"restApp/repo"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// TaxRateController provides REST API methods for tax rates.
+// The changed rates apply only to the line items added afterwards.
+type TaxRateController struct {
+	taxRateRepo repo.ITaxRateRepository
+	controller
+}
+
+func (ctl *TaxRateController) getTaxRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	rate, err := ctl.taxRateRepo.GetTaxRateByID(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no tax rate with id %d in the database", id))
+
+		return
+	}
+
+	data, err := json.Marshal(rate)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *TaxRateController) getTaxRates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params, err := parseListParams(query)
+
+	if err != nil {
+		ctl.handleParamError(w, r, err)
+
+		return
+	}
+
+	filter := &repo.TaxRateFilter{
+		Category:     query.Get("category"),
+		Jurisdiction: query.Get("jurisdiction"),
+	}
+
+	rates, total, err := ctl.taxRateRepo.GetAllTaxRates(r.Context(), filter, params)
+
+	if paramErr := listParamError(err); paramErr != nil {
+		ctl.handleParamError(w, r, paramErr)
+
+		return
+	}
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			"Couldn't extract any entry from the tax rates database")
+
+		return
+	}
+
+	var lastID int64
+
+	if len(rates) > 0 {
+		lastID = rates[len(rates)-1].ID
+	}
+
+	data, err := json.Marshal(newCollection(rates, total, params, lastID, len(rates)))
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *TaxRateController) addTaxRate(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	rate := new(repo.TaxRate)
+	err = json.Unmarshal(data, rate)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
+			"Couldn't parse JSON data")
+
+		return
+	}
+
+	err = rate.Validate()
+
+	if err != nil {
+		ctl.handleValidationError(w, r, err)
+
+		return
+	}
+
+	err = ctl.taxRateRepo.AddTaxRate(r.Context(), rate)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The tax rate doesn't exist")
+
+		return
+	}
+
+	data, err = json.Marshal(rate)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendCreated(w, resourceLocation(r, rate.ID), data)
+}
+
+func (ctl *TaxRateController) replaceTaxRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	rate := new(repo.TaxRate)
+	err = json.Unmarshal(data, rate)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeInvalidJSON,
+			"Couldn't parse JSON data")
+
+		return
+	}
+
+	// The ID is taken from the URL rather than from the body.
+	rate.ID = int64(id)
+	ctl.updateTaxRate(w, r, rate)
+}
+
+func (ctl *TaxRateController) patchTaxRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		ctl.handleWebError(w, r, http.StatusBadRequest, codeBadRequest,
+			"Couldn't read body")
+
+		return
+	}
+
+	current, err := ctl.taxRateRepo.GetTaxRateByID(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err,
+			fmt.Sprintf("There is no tax rate with id %d in the database", id))
+
+		return
+	}
+
+	rate := new(repo.TaxRate)
+
+	if !ctl.patchEntity(w, r, data, current, rate) {
+		return
+	}
+
+	rate.ID = current.ID
+	ctl.updateTaxRate(w, r, rate)
+}
+
+// updateTaxRate validates the tax rate and stores it in the database
+// replacing the previous state.
+func (ctl *TaxRateController) updateTaxRate(w http.ResponseWriter, r *http.Request,
+	rate *repo.TaxRate) {
+	err := rate.Validate()
+
+	if err != nil {
+		ctl.handleValidationError(w, r, err)
+
+		return
+	}
+
+	err = ctl.taxRateRepo.UpdateTaxRate(r.Context(), rate)
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The tax rate doesn't exist")
+
+		return
+	}
+
+	data, err := json.Marshal(rate)
+
+	if err != nil {
+		ctl.handleInternalError(r, "Couldn't marshal data to JSON", err)
+		ctl.handleWebError(w, r, http.StatusInternalServerError, codeInternalError,
+			"Couldn't marshal data to JSON")
+
+		return
+	}
+
+	ctl.sendData(w, data)
+}
+
+func (ctl *TaxRateController) deleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		ctl.handleParamError(w, r, &paramError{"id",
+			fmt.Sprintf("Incorrect parameter for id: %v", params["id"])})
+
+		return
+	}
+
+	err = ctl.taxRateRepo.DeleteTaxRate(r.Context(), int64(id))
+
+	if err != nil {
+		ctl.handleRepoError(w, r, err, "The tax rate doesn't exist")
+
+		return
+	}
+
+	ctl.sendSuccess(w, "Deleted successfully")
+}
+
+// taxRatePolicy lists the roles allowed to use the routes of the controller besides the admins.
+// Only the admins may change the tax rates.
+var taxRatePolicy = Policy{
+	"getTaxRate":  {auth.RoleSales},
+	"getTaxRates": {auth.RoleSales},
+}
+
+// SetupRoutes sets up routes for the controller.
+func (ctl *TaxRateController) SetupRoutes(router *mux.Router) {
+	router.Use(jsonMiddleware, ctl.authorize(taxRatePolicy))
+
+	router.HandleFunc("/{id:[0-9]+}", ctl.getTaxRate).Methods("GET").Name("getTaxRate")
+	router.HandleFunc("/", ctl.getTaxRates).Methods("GET").Name("getTaxRates")
+	router.HandleFunc("/", ctl.addTaxRate).Methods("POST").Name("addTaxRate")
+	router.HandleFunc("/{id:[0-9]+}", ctl.replaceTaxRate).Methods("PUT").Name("replaceTaxRate")
+	router.HandleFunc("/{id:[0-9]+}", ctl.patchTaxRate).Methods("PATCH").Name("patchTaxRate")
+	router.HandleFunc("/{id:[0-9]+}", ctl.deleteTaxRate).Methods("DELETE").Name("deleteTaxRate")
+}
+
+// NewTaxRateController returns a new controller for the REST API operations on tax rates.
+func NewTaxRateController(repository repo.ITaxRateRepository, logger *logging.Logger) *TaxRateController {
+	ctl := new(TaxRateController)
+
+	ctl.taxRateRepo = repository
+	ctl.logger = logger
+
+	return ctl
+}
@@ -1,2 +0,0 @@
-INSERT INTO customers (company_name, company_address, tax_id, email, phone_number) VALUES
-($1, $2, $3, $4, $5);
\ No newline at end of file
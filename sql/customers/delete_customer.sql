@@ -1,2 +0,0 @@
-DELETE FROM customers c
-WHERE c.id = $1;
\ No newline at end of file
@@ -1,2 +0,0 @@
-SELECT *
-FROM customers;
\ No newline at end of file
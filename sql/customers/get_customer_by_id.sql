@@ -1,3 +0,0 @@
-SELECT *
-FROM customers c
-WHERE c.id = $1;
\ No newline at end of file
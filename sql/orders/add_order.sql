@@ -1,2 +0,0 @@
-INSERT INTO orders (customer_id, contract_date) VALUES
-($1, $2);
\ No newline at end of file
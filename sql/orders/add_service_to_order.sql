@@ -1,2 +0,0 @@
-INSERT INTO orders_to_services (order_id, service_id) VALUES
-($1, $2);
\ No newline at end of file
@@ -1,2 +0,0 @@
-DELETE FROM orders o
-WHERE o.id = $1;
\ No newline at end of file
@@ -1,2 +0,0 @@
-SELECT *
-FROM orders;
\ No newline at end of file
@@ -1,3 +0,0 @@
-SELECT *
-FROM orders o
-WHERE o.id = $1;
\ No newline at end of file
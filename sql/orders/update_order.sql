@@ -1,3 +0,0 @@
-UPDATE orders
-SET contract_date = $2
-WHERE id = $1;
\ No newline at end of file
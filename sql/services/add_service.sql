@@ -1,2 +0,0 @@
-INSERT INTO services (title, service_description, price) VALUES
-($1, $2, $3);
\ No newline at end of file
@@ -1,2 +0,0 @@
-SELECT *
-FROM services;
\ No newline at end of file
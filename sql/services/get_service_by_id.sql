@@ -1,3 +0,0 @@
-SELECT *
-FROM services s
-WHERE s.id = $1;
\ No newline at end of file
@@ -1,3 +0,0 @@
-UPDATE services
-SET title = $2, service_description = $3, price = $4
-WHERE id = $1;
\ No newline at end of file